@@ -0,0 +1,101 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// backfillWindow is the creation time range of the workflow runs listed at once. The GitHub API
+// returns at most 1000 runs per filtered list, so the backfill walks small windows from the
+// oldest to the newest and checkpoints the end of each window once all its runs are processed.
+const backfillWindow = 24 * time.Hour
+
+// runBackfill processes the completed workflow runs created between backfill.since and backfill.until
+// for each configured repository, the same way as the workflow_run webhooks. The backfill of a repository
+// stops at the first run that fails, and is resumed from the window of that run on the next start.
+func (rec *githubactionsannotationsreceiver) runBackfill(ctx context.Context, now time.Time) {
+	ctx = withRateLimitReserve(ctx, rec.config.Backfill.MinRateLimitRemaining)
+	for _, fullName := range rec.config.Backfill.Repositories {
+		err := rec.ghClients.retryStale(newRepository(fullName).GetOwner().GetLogin(), func() error {
+			return rec.backfillRepository(ctx, fullName, now)
+		})
+		if err != nil {
+			rec.logger.Error("Backfill failed", zap.String("github.repository", fullName), zap.Error(err))
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (rec *githubactionsannotationsreceiver) backfillRepository(ctx context.Context, fullName string, now time.Time) error {
+	repo := newRepository(fullName)
+	ghClient, err := rec.ghClients.forRepository(ctx, repo)
+	if err != nil {
+		return err
+	}
+	until, err := rec.backfillUntil(ctx, fullName, now)
+	if err != nil {
+		return err
+	}
+	checkpointName := fmt.Sprintf("backfill_%s", fullName)
+	from, err := rec.store.loadCheckpoint(ctx, checkpointName)
+	if err != nil {
+		return err
+	}
+	if from.Before(rec.config.Backfill.Since) {
+		from = rec.config.Backfill.Since
+	}
+	if !from.Before(until) {
+		rec.logger.Debug("Backfill already completed", zap.String("github.repository", fullName))
+		return nil
+	}
+	rec.logger.Info("Starting backfill", zap.String("github.repository", fullName), zap.Time("from", from), zap.Time("until", until))
+	for from.Before(until) {
+		to := from.Add(backfillWindow)
+		if to.After(until) {
+			to = until
+		}
+		runs, err := rec.listCompletedWorkflowRuns(ctx, ghClient, repo, from, to)
+		if err != nil {
+			return err
+		}
+		for _, run := range runs {
+			// the checkpoint must not move past a failed run, the next backfill starts again from this window
+			if err := rec.collectWorkflowRun(ctx, ghClient, repo, run); err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+		if err := rec.store.saveCheckpoint(ctx, checkpointName, to); err != nil {
+			return fmt.Errorf("failed to save backfill checkpoint: %w", err)
+		}
+		rec.logger.Debug("Backfilled workflow runs", zap.String("github.repository", fullName), zap.Time("until", to), zap.Int("count", len(runs)))
+		from = to
+	}
+	rec.logger.Info("Backfill completed", zap.String("github.repository", fullName))
+	return nil
+}
+
+// backfillUntil returns backfill.until or, if it is not set, the start time of the first backfill of the
+// repository. That start time is saved with the checkpoint, so that a restart does not backfill the runs
+// completed since then, which were delivered as webhooks.
+func (rec *githubactionsannotationsreceiver) backfillUntil(ctx context.Context, fullName string, now time.Time) (time.Time, error) {
+	if !rec.config.Backfill.Until.IsZero() {
+		return rec.config.Backfill.Until, nil
+	}
+	checkpointName := fmt.Sprintf("backfill_until_%s", fullName)
+	until, err := rec.store.loadCheckpoint(ctx, checkpointName)
+	if err != nil || !until.IsZero() {
+		return until, err
+	}
+	if err := rec.store.saveCheckpoint(ctx, checkpointName, now); err != nil {
+		return now, fmt.Errorf("failed to save backfill end: %w", err)
+	}
+	return now, nil
+}
@@ -0,0 +1,183 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newBackfillTestServer starts a fake GitHub API listing the completed run 1 in the window
+// starting at since, two jobs for every run and a single annotation for every job.
+// It records the created filter of each workflow runs list request.
+func newBackfillTestServer(t *testing.T, since time.Time, rateLimitRemaining int, rateLimitReset time.Time) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var createdFilters []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/rate_limit":
+			fmt.Fprintf(w, `{"resources":{"core":{"limit":5000,"remaining":%d,"reset":%d}}}`, rateLimitRemaining, rateLimitReset.Unix())
+		case r.URL.Path == "/repos/owner/repo/actions/runs":
+			created := r.URL.Query().Get("created")
+			mu.Lock()
+			createdFilters = append(createdFilters, created)
+			mu.Unlock()
+			runs := &github.WorkflowRuns{TotalCount: github.Int(0)}
+			if strings.HasPrefix(created, since.UTC().Format(time.RFC3339)) {
+				runs = &github.WorkflowRuns{TotalCount: github.Int(1), WorkflowRuns: []*github.WorkflowRun{newWorkflowRunEvent(1).GetWorkflowRun()}}
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(runs))
+		case strings.HasSuffix(r.URL.Path, "/jobs"):
+			jobs := []*github.WorkflowJob{newWorkflowJobEvent(1).GetWorkflowJob(), newWorkflowJobEvent(2).GetWorkflowJob()}
+			require.NoError(t, json.NewEncoder(w).Encode(&github.Jobs{TotalCount: github.Int(len(jobs)), Jobs: jobs}))
+		default:
+			fmt.Fprint(w, `[{"path":"main.go","start_line":1,"annotation_level":"failure","message":"boom"}]`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), createdFilters...)
+	}
+}
+
+// withFailingCheckRun wraps server into a server failing to list the annotations of the check run
+func withFailingCheckRun(t *testing.T, server *httptest.Server, checkRunID int64) *httptest.Server {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == fmt.Sprintf("/repos/owner/repo/check-runs/%d/annotations", checkRunID) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(failing.Close)
+	return failing
+}
+
+// newStoreTestReceiver returns a test receiver with a pending store, without starting it
+func newStoreTestReceiver(t *testing.T, cfg *Config, ghServer *httptest.Server) *githubactionsannotationsreceiver {
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), ghServer)
+	var err error
+	rec.store, err = newPendingStore(context.Background(), newStorageHost(storageID, newMemoryStorage()), &storageID, rec.settings.ID)
+	require.NoError(t, err)
+	return rec
+}
+
+func TestBackfillCheckpointDoesNotMovePastFailedRun(t *testing.T) {
+	// arrange
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := newTestConfig(t)
+	cfg.Backfill = BackfillConfig{
+		Repositories: []string{"owner/repo"},
+		Since:        since,
+		Until:        since.Add(2 * backfillWindow),
+	}
+	ghServer, _ := newBackfillTestServer(t, since, 4999, time.Now())
+	rec := newStoreTestReceiver(t, cfg, withFailingCheckRun(t, ghServer, 2))
+
+	// act
+	err := rec.backfillRepository(context.Background(), "owner/repo", cfg.Backfill.Until)
+
+	// assert
+	assert.ErrorContains(t, err, "failed to collect workflow run 1")
+	checkpoint, err := rec.store.loadCheckpoint(context.Background(), "backfill_owner/repo")
+	require.NoError(t, err)
+	assert.True(t, checkpoint.IsZero())
+}
+
+func TestBackfillProcessesPastWorkflowRunsAndResumesFromCheckpoint(t *testing.T) {
+	// arrange
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := newTestConfig(t)
+	cfg.Backfill = BackfillConfig{
+		Repositories:          []string{"owner/repo"},
+		Since:                 since,
+		Until:                 since.Add(2 * backfillWindow),
+		MinRateLimitRemaining: 1,
+	}
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	host := newStorageHost(storageID, newMemoryStorage())
+	ghServer, createdFilters := newBackfillTestServer(t, since, 4999, time.Now())
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, ghServer)
+
+	// act
+	require.NoError(t, rec.Start(context.Background(), host))
+	require.Eventually(t, func() bool {
+		checkpoint, err := rec.store.loadCheckpoint(context.Background(), "backfill_owner/repo")
+		return err == nil && checkpoint.Equal(cfg.Backfill.Until)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	restarted := newTestReceiver(t, cfg, sink, ghServer)
+	require.NoError(t, restarted.Start(context.Background(), host))
+	require.NoError(t, restarted.Shutdown(context.Background()))
+
+	// assert
+	assert.Equal(t, []string{
+		"2024-01-01T00:00:00Z..2024-01-02T00:00:00Z",
+		"2024-01-02T00:00:00Z..2024-01-03T00:00:00Z",
+	}, createdFilters())
+	require.Equal(t, 2, sink.LogRecordCount())
+	logRecord := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	assert.Equal(t, newWorkflowJobEvent(1).GetWorkflowJob().GetCompletedAt().Unix(), logRecord.Timestamp().AsTime().Unix())
+}
+
+func TestBackfillWithoutUntilDoesNotExtendOnRestart(t *testing.T) {
+	// arrange
+	since := time.Now().UTC().Truncate(time.Hour).Add(-2 * backfillWindow)
+	cfg := newTestConfig(t)
+	cfg.Backfill = BackfillConfig{
+		Repositories: []string{"owner/repo"},
+		Since:        since,
+	}
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	host := newStorageHost(storageID, newMemoryStorage())
+	ghServer, createdFilters := newBackfillTestServer(t, since, 4999, time.Now())
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), ghServer)
+	require.NoError(t, rec.Start(context.Background(), host))
+	var until time.Time
+	require.Eventually(t, func() bool {
+		var err error
+		until, err = rec.store.loadCheckpoint(context.Background(), "backfill_until_owner/repo")
+		if err != nil || until.IsZero() {
+			return false
+		}
+		checkpoint, err := rec.store.loadCheckpoint(context.Background(), "backfill_owner/repo")
+		return err == nil && checkpoint.Equal(until)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	filters := createdFilters()
+
+	// act
+	restarted := newTestReceiver(t, cfg, consumertest.NewNop(), ghServer)
+	core, logs := observer.New(zap.DebugLevel)
+	restarted.logger = zap.New(core)
+	require.NoError(t, restarted.Start(context.Background(), host))
+	require.Eventually(t, func() bool { return logs.FilterMessage("Backfill already completed").Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, restarted.Shutdown(context.Background()))
+
+	// assert
+	assert.Len(t, filters, 3)
+	assert.Equal(t, filters, createdFilters())
+	checkpoint, err := restarted.store.loadCheckpoint(context.Background(), "backfill_owner/repo")
+	require.NoError(t, err)
+	assert.True(t, checkpoint.Equal(until))
+}
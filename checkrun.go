@@ -0,0 +1,72 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/google/go-github/v66/github"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// githubActionsAppSlug is the app creating the check runs of workflow jobs
+const githubActionsAppSlug = "github-actions"
+
+// handleCheckRunEvent queues completed check runs of the allowed GitHub Apps
+func (rec *githubactionsannotationsreceiver) handleCheckRunEvent(ctx context.Context, event *github.CheckRunEvent, w http.ResponseWriter, r *http.Request) {
+	rec.logger.Debug("Handling check run event", zap.Int64("check_run.id", event.GetCheckRun().GetID()))
+	if event.GetAction() != "completed" || !rec.isCheckRunAppAllowed(event.GetCheckRun().GetApp().GetSlug()) || !rec.filter.allowsCheckRun(event.GetRepo(), event.GetCheckRun()) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	dedupKeys := []string{checkRunDedupKey(event.GetCheckRun().GetID())}
+	rec.enqueue(ctx, workItem{checkRunEvent: event, dedupKeys: dedupKeys}, w, r)
+}
+
+func (rec *githubactionsannotationsreceiver) isCheckRunAppAllowed(appSlug string) bool {
+	if appSlug == githubActionsAppSlug {
+		return false
+	}
+	return slices.Contains(rec.config.CheckRuns.Apps, "*") || slices.Contains(rec.config.CheckRuns.Apps, appSlug)
+}
+
+// newCheckRunInfoFields returns a function prepending the check run identity to log fields
+func newCheckRunInfoFields(event *github.CheckRunEvent) func(fields ...zap.Field) []zap.Field {
+	return func(fields ...zap.Field) []zap.Field {
+		checkRunInfoFields := []zap.Field{
+			zap.String("github.repository", event.GetRepo().GetFullName()),
+			zap.Int64("github.check_run.id", event.GetCheckRun().GetID()),
+			zap.String("github.check_run.name", event.GetCheckRun().GetName()),
+			zap.String("github.app.slug", event.GetCheckRun().GetApp().GetSlug()),
+		}
+		return append(checkRunInfoFields, fields...)
+	}
+}
+
+// processCheckRunEvent emits the annotations of the check run as log records.
+// Check runs are not part of a workflow run, so they are not emitted as traces nor metrics.
+func (rec *githubactionsannotationsreceiver) processCheckRunEvent(
+	ctx context.Context,
+	ghClient *github.Client,
+	withCheckRunInfoFields func(fields ...zap.Field) []zap.Field,
+	event *github.CheckRunEvent,
+) error {
+	if rec.logsConsumer == nil {
+		return nil
+	}
+	annotations, err := rec.fetchAnnotations(ctx, ghClient, withCheckRunInfoFields, event.GetRepo(), event.GetCheckRun().GetID())
+	if err != nil {
+		return err
+	}
+	annotations = rec.filterAnnotations(ctx, annotations, withCheckRunInfoFields)
+
+	checkRun := mapCheckRun(event.GetCheckRun())
+	repository := mapRepository(event.GetRepo())
+	_, err = rec.processAnnotations(ctx, annotations, repository, withCheckRunInfoFields, func(logRecord *plog.LogRecord, line *checkRunAnnotation) error {
+		logLine := parseAnnotationToLogLine(checkRun.CompletedAt, &line.CheckRunAnnotation)
+		attachCheckRunData(logRecord, rec.config.Severity, repository, checkRun, mapAnnotation(line), logLine)
+		return nil
+	})
+	return err
+}
@@ -3,8 +3,10 @@ package opentelemetrygithubactionsannotationsreceiver
 import (
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/confighttp"
 	"go.opentelemetry.io/collector/config/configopaque"
 	"go.uber.org/multierr"
@@ -16,32 +18,147 @@ const (
 	defaultRetryInitialInterval = 1 * time.Second
 	defaultRetryMaxInterval     = 30 * time.Minute
 	defaultRetryMaxElapsedTime  = 5 * time.Minute
+	defaultRetryRequeueDelay    = 5 * time.Minute
+	defaultRetryMaxRequeues     = 12
+	defaultQueueNumWorkers      = 4
+	defaultQueueSize            = 1000
+	defaultDedupTTL             = 24 * time.Hour
+	defaultDedupSize            = 10000
+	defaultRateLimitReserve     = 100
+	defaultBackfillMinRemaining = 500
+	defaultPollingInterval      = 5 * time.Minute
+	defaultPollingLookback      = time.Hour
+	defaultPollingMinRemaining  = 500
+	defaultNoticeSeverity       = "INFO"
+	defaultWarningSeverity      = "WARN"
+	defaultFailureSeverity      = "ERROR"
 )
 
 type Config struct {
 	confighttp.ServerConfig `mapstructure:",squash"`
-	Path                    string              `mapstructure:"path"`
-	WebhookSecret           configopaque.String `mapstructure:"webhook_secret"`
-	GitHubAuth              GitHubAuth          `mapstructure:"github_auth"`
-	Retry                   RetryConfig         `mapstructure:"retry"`
-	BatchSize               int                 `mapstructure:"batch_size"`
-	CustomServiceName       string              `mapstructure:"custom_service_name"`
-	ServiceNamePrefix       string              `mapstructure:"service_name_prefix"`
-	ServiceNameSuffix       string              `mapstructure:"service_name_suffix"`
+	Path                    string                `mapstructure:"path"`
+	WebhookSecret           configopaque.String   `mapstructure:"webhook_secret"`
+	WebhookSecrets          []configopaque.String `mapstructure:"webhook_secrets"`
+	WebhookSecretFiles      []string              `mapstructure:"webhook_secret_files"`
+	GitHubAuth              GitHubAuth            `mapstructure:"github_auth"`
+	RateLimit               RateLimitConfig       `mapstructure:"rate_limit"`
+	Retry                   RetryConfig           `mapstructure:"retry"`
+	FetchTimeout            time.Duration         `mapstructure:"fetch_timeout"`
+	Queue                   QueueConfig           `mapstructure:"queue"`
+	Dedup                   DedupConfig           `mapstructure:"dedup"`
+	StorageID               *component.ID         `mapstructure:"storage"`
+	CheckRuns               CheckRunsConfig       `mapstructure:"check_runs"`
+	Filters                 FiltersConfig         `mapstructure:"filters"`
+	Backfill                BackfillConfig        `mapstructure:"backfill"`
+	Polling                 PollingConfig         `mapstructure:"polling"`
+	BatchSize               int                   `mapstructure:"batch_size"`
+	CustomServiceName       string                `mapstructure:"custom_service_name"`
+	ServiceNamePrefix       string                `mapstructure:"service_name_prefix"`
+	ServiceNameSuffix       string                `mapstructure:"service_name_suffix"`
+	Severity                SeverityConfig        `mapstructure:"severity"`
 }
 
+// RetryConfig configures the retries of the GitHub API calls and of the next consumers. The webhooks are
+// acknowledged before they are processed, so GitHub never redelivers a webhook whose processing fails.
 type RetryConfig struct {
 	InitialInterval time.Duration `mapstructure:"initial_interval"`
 	MaxInterval     time.Duration `mapstructure:"max_interval"`
 	MaxElapsedTime  time.Duration `mapstructure:"max_elapsed_time"`
+	// RequeueDelay is the delay before a webhook is queued again once the GitHub API retries are exhausted
+	// or fetch_timeout expires. Zero disables the requeue. The webhook stays persisted in the storage
+	// extension until it is processed, so it is only replayed after a restart if storage is set.
+	RequeueDelay time.Duration `mapstructure:"requeue_delay"`
+	// MaxRequeues is the number of times a webhook is queued again before it is dropped and removed from the
+	// storage extension. The count starts again from zero when a persisted webhook is replayed on start.
+	MaxRequeues int `mapstructure:"max_requeues"`
+}
+
+// RateLimitConfig configures how the GitHub API rate limit is spent
+type RateLimitConfig struct {
+	// Reserve is the number of requests of the rate limit left unused. Once fewer requests remain,
+	// the GitHub API calls wait for the rate limit reset, so other clients sharing the same token
+	// or installation keep some budget.
+	Reserve int `mapstructure:"reserve"`
+}
+
+// QueueConfig configures the in-memory queue between the webhook endpoint and the workers
+// fetching and emitting the annotations
+type QueueConfig struct {
+	// NumWorkers is the number of webhooks processed concurrently. Zero uses the default.
+	NumWorkers int `mapstructure:"num_workers"`
+	// QueueSize is the number of webhooks waiting to be processed before the overflow policy applies
+	QueueSize int `mapstructure:"queue_size"`
+	// OverflowPolicy is either "reject" (default), answering 503 to new webhooks while the queue is full,
+	// or "drop_oldest", dropping the oldest queued webhook to make room for the new one
+	OverflowPolicy string `mapstructure:"overflow_policy"`
+}
+
+// DedupConfig configures the suppression of webhooks already accepted, either because
+// GitHub redelivered them or because the same workflow job attempt was delivered twice
+type DedupConfig struct {
+	// TTL is how long an accepted webhook is remembered
+	TTL time.Duration `mapstructure:"ttl"`
+	// Size is the maximum number of remembered keys. Zero disables the deduplication.
+	Size int `mapstructure:"size"`
+}
+
+// CheckRunsConfig configures the collection of annotations from check_run events,
+// created by GitHub Apps such as linters or code scanners
+type CheckRunsConfig struct {
+	// Apps is the allow-list of GitHub App slugs whose check runs are collected, "*" allows all of them.
+	// Check runs of the github-actions app are always skipped since they are collected from workflow_job events.
+	Apps []string `mapstructure:"apps"`
+}
+
+// BackfillConfig configures the collection of the annotations of past workflow runs when the
+// receiver starts. The progress is checkpointed in the storage extension, which is required, so a
+// restarted backfill resumes where it stopped.
+type BackfillConfig struct {
+	// Repositories are the "owner/name" repositories to backfill. Empty disables the backfill.
+	Repositories []string `mapstructure:"repositories"`
+	// Since is the creation time of the oldest workflow run to backfill
+	Since time.Time `mapstructure:"since"`
+	// Until is the creation time of the newest workflow run to backfill. Zero is the start time of the receiver
+	// when a repository is backfilled for the first time, it is kept across restarts.
+	Until time.Time `mapstructure:"until"`
+	// MinRateLimitRemaining is the rate limit reserve of the backfill requests, which pause until the GitHub API
+	// rate limit resets when fewer requests remain, so they are left to the webhooks processing. It applies
+	// instead of rate_limit.reserve when greater.
+	MinRateLimitRemaining int `mapstructure:"min_rate_limit_remaining"`
+}
+
+// PollingConfig configures the periodic listing of the completed workflow runs, as an alternative
+// to the webhooks for the repositories GitHub cannot deliver them from. The time of the last poll is
+// checkpointed in the storage extension, which is required, so a restarted receiver does not emit the same jobs again.
+type PollingConfig struct {
+	// Repositories are the "owner/name" repositories to poll
+	Repositories []string `mapstructure:"repositories"`
+	// Organizations are the organizations whose repositories are all polled
+	Organizations []string `mapstructure:"organizations"`
+	// Interval is the time between two polls
+	Interval time.Duration `mapstructure:"interval"`
+	// Lookback is how far back the first poll goes. Each poll also lists the runs created within lookback
+	// before the previous poll, to collect the runs that were still in progress then.
+	Lookback time.Duration `mapstructure:"lookback"`
+	// MinRateLimitRemaining is the rate limit reserve of the polling requests, which pause until the GitHub API
+	// rate limit resets when fewer requests remain, so they are left to the webhooks processing. It applies
+	// instead of rate_limit.reserve when greater.
+	MinRateLimitRemaining int `mapstructure:"min_rate_limit_remaining"`
 }
 
 type GitHubAuth struct {
-	AppID          int64               `mapstructure:"app_id"`
+	AppID int64 `mapstructure:"app_id"`
+	// InstallationID is the GitHub App installation used for the backfill, the polling and the webhooks
+	// not sent by an installation. If not set, the installation is the one sending each webhook, or the one
+	// on the account owning the backfilled or polled repositories, so the receiver serves all of them.
 	InstallationID int64               `mapstructure:"installation_id"`
 	PrivateKey     configopaque.String `mapstructure:"private_key"`
 	PrivateKeyPath string              `mapstructure:"private_key_path"`
 	Token          configopaque.String `mapstructure:"token"`
+	// BaseURL and UploadURL point to a GitHub Enterprise Server instance.
+	// UploadURL defaults to BaseURL.
+	BaseURL   string `mapstructure:"base_url"`
+	UploadURL string `mapstructure:"upload_url"`
 }
 
 // Validate checks if the receiver configuration is valid
@@ -56,16 +173,138 @@ func (cfg *Config) Validate() error {
 			err = multierr.Append(err, fmt.Errorf("path must be a relative URL. e.g. \"/events\""))
 		}
 	}
+	for _, path := range cfg.WebhookSecretFiles {
+		if path == "" {
+			err = multierr.Append(err, fmt.Errorf("webhook_secret_files must not contain empty paths"))
+		}
+	}
 	if cfg.GitHubAuth.Token == "" && cfg.GitHubAuth.AppID == 0 {
 		err = multierr.Append(err, fmt.Errorf("either github_auth.token or github_auth.app_id must be set"))
 	}
 	if cfg.GitHubAuth.AppID != 0 {
-		if cfg.GitHubAuth.InstallationID == 0 {
-			err = multierr.Append(err, fmt.Errorf("github_auth.installation_id must be set if github_auth.app_id is set"))
-		}
 		if cfg.GitHubAuth.PrivateKey == "" && cfg.GitHubAuth.PrivateKeyPath == "" {
 			err = multierr.Append(err, fmt.Errorf("either github_auth.private_key or github_auth.private_key_path must be set if github_auth.app_id is set"))
 		}
 	}
+	if cfg.GitHubAuth.BaseURL != "" {
+		err = multierr.Append(err, validateAbsoluteURL("github_auth.base_url", cfg.GitHubAuth.BaseURL))
+	}
+	if cfg.GitHubAuth.UploadURL != "" {
+		if cfg.GitHubAuth.BaseURL == "" {
+			err = multierr.Append(err, fmt.Errorf("github_auth.base_url must be set if github_auth.upload_url is set"))
+		}
+		err = multierr.Append(err, validateAbsoluteURL("github_auth.upload_url", cfg.GitHubAuth.UploadURL))
+	}
+	if cfg.Retry.RequeueDelay < 0 {
+		err = multierr.Append(err, fmt.Errorf("retry.requeue_delay must not be negative"))
+	}
+	if cfg.Retry.RequeueDelay > 0 && cfg.Retry.MaxRequeues <= 0 {
+		err = multierr.Append(err, fmt.Errorf("retry.max_requeues must be positive when retry.requeue_delay is set"))
+	}
+	if cfg.FetchTimeout < 0 {
+		err = multierr.Append(err, fmt.Errorf("fetch_timeout must not be negative"))
+	}
+	if cfg.RateLimit.Reserve < 0 {
+		err = multierr.Append(err, fmt.Errorf("rate_limit.reserve must not be negative"))
+	}
+	if cfg.BatchSize < 0 {
+		err = multierr.Append(err, fmt.Errorf("batch_size must not be negative"))
+	}
+	if cfg.Queue.NumWorkers < 0 {
+		err = multierr.Append(err, fmt.Errorf("queue.num_workers must not be negative"))
+	}
+	if cfg.Queue.QueueSize < 0 {
+		err = multierr.Append(err, fmt.Errorf("queue.queue_size must not be negative"))
+	}
+	if cfg.Queue.OverflowPolicy == overflowPolicyDropOldest && cfg.Queue.QueueSize == 0 {
+		err = multierr.Append(err, fmt.Errorf("queue.queue_size must be greater than 0 if queue.overflow_policy is %q", overflowPolicyDropOldest))
+	}
+	switch cfg.Queue.OverflowPolicy {
+	case "", overflowPolicyReject, overflowPolicyDropOldest:
+	default:
+		err = multierr.Append(err, fmt.Errorf("queue.overflow_policy must be either %q or %q", overflowPolicyReject, overflowPolicyDropOldest))
+	}
+	if cfg.Dedup.Size < 0 {
+		err = multierr.Append(err, fmt.Errorf("dedup.size must not be negative"))
+	}
+	if cfg.Dedup.Size > 0 && cfg.Dedup.TTL <= 0 {
+		err = multierr.Append(err, fmt.Errorf("dedup.ttl must be greater than 0 if dedup.size is set"))
+	}
+	err = multierr.Append(err, cfg.Filters.validate())
+	err = multierr.Append(err, cfg.Backfill.validate())
+	if len(cfg.Backfill.Repositories) > 0 && cfg.StorageID == nil {
+		err = multierr.Append(err, fmt.Errorf("storage must be set if backfill.repositories is set, to checkpoint the backfill progress"))
+	}
+	err = multierr.Append(err, cfg.Polling.validate())
+	if cfg.Polling.enabled() && cfg.StorageID == nil {
+		err = multierr.Append(err, fmt.Errorf("storage must be set if polling is enabled, to checkpoint the last poll"))
+	}
+	for _, s := range []struct{ level, severity string }{
+		{annotationLevelNotice, cfg.Severity.Notice},
+		{annotationLevelWarning, cfg.Severity.Warning},
+		{annotationLevelFailure, cfg.Severity.Failure},
+	} {
+		if s.severity != "" && !isValidSeverity(s.severity) {
+			err = multierr.Append(err, fmt.Errorf("severity.%s must be one of TRACE, DEBUG, INFO, WARN, ERROR or FATAL, got %q", s.level, s.severity))
+		}
+	}
 	return err
 }
+
+func (cfg *BackfillConfig) validate() error {
+	err := validateRepositories("backfill.repositories", cfg.Repositories)
+	if len(cfg.Repositories) > 0 && cfg.Since.IsZero() {
+		err = multierr.Append(err, fmt.Errorf("backfill.since must be set if backfill.repositories is set"))
+	}
+	if !cfg.Until.IsZero() && !cfg.Until.After(cfg.Since) {
+		err = multierr.Append(err, fmt.Errorf("backfill.until must be after backfill.since"))
+	}
+	if cfg.MinRateLimitRemaining < 0 {
+		err = multierr.Append(err, fmt.Errorf("backfill.min_rate_limit_remaining must not be negative"))
+	}
+	return err
+}
+
+func (cfg *PollingConfig) validate() error {
+	err := validateRepositories("polling.repositories", cfg.Repositories)
+	for _, organization := range cfg.Organizations {
+		if organization == "" || strings.Contains(organization, "/") {
+			err = multierr.Append(err, fmt.Errorf("polling.organizations must be organization logins, got %q", organization))
+		}
+	}
+	if cfg.Interval < 0 || cfg.enabled() && cfg.Interval == 0 {
+		err = multierr.Append(err, fmt.Errorf("polling.interval must be greater than 0"))
+	}
+	if cfg.Lookback < 0 {
+		err = multierr.Append(err, fmt.Errorf("polling.lookback must not be negative"))
+	}
+	if cfg.MinRateLimitRemaining < 0 {
+		err = multierr.Append(err, fmt.Errorf("polling.min_rate_limit_remaining must not be negative"))
+	}
+	return err
+}
+
+func (cfg *PollingConfig) enabled() bool {
+	return len(cfg.Repositories) > 0 || len(cfg.Organizations) > 0
+}
+
+func validateRepositories(name string, repositories []string) error {
+	var err error
+	for _, repository := range repositories {
+		if owner, repo, ok := strings.Cut(repository, "/"); !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			err = multierr.Append(err, fmt.Errorf("%s must be in the \"owner/name\" form, got %q", name, repository))
+		}
+	}
+	return err
+}
+
+func validateAbsoluteURL(name string, rawURL string) error {
+	parsedUrl, err := url.ParseRequestURI(rawURL)
+	if err != nil {
+		return fmt.Errorf("%s must be a valid URL: %s", name, err)
+	}
+	if parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https" || parsedUrl.Host == "" {
+		return fmt.Errorf("%s must be an absolute http(s) URL. e.g. \"https://github.example.com\"", name)
+	}
+	return nil
+}
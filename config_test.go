@@ -2,9 +2,11 @@ package opentelemetrygithubactionsannotationsreceiver_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	opentelemetrygithubactionsannotationsreceiver "github.com/v1v/opentelemetry-github-actions-annotations-receiver"
+	"go.opentelemetry.io/collector/component"
 )
 
 func TestConfigValidateSuccess(t *testing.T) {
@@ -98,5 +100,232 @@ func TestConfigValidateOnlyAppIdShouldFail(t *testing.T) {
 	err := config.Validate()
 
 	// assert
-	assert.EqualError(t, err, "github_auth.installation_id must be set if github_auth.app_id is set; either github_auth.private_key or github_auth.private_key_path must be set if github_auth.app_id is set")
+	assert.EqualError(t, err, "either github_auth.private_key or github_auth.private_key_path must be set if github_auth.app_id is set")
+}
+
+func TestConfigValidateUnknownSeverityShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		Severity: opentelemetrygithubactionsannotationsreceiver.SeverityConfig{
+			Notice:  "INFO",
+			Warning: "WARNING",
+			Failure: "ERROR",
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "severity.warning must be one of TRACE, DEBUG, INFO, WARN, ERROR or FATAL, got \"WARNING\"")
+}
+
+func TestConfigValidateInvalidQueueShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		Queue: opentelemetrygithubactionsannotationsreceiver.QueueConfig{
+			NumWorkers:     -1,
+			QueueSize:      -1,
+			OverflowPolicy: "block",
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "queue.num_workers must not be negative; queue.queue_size must not be negative; queue.overflow_policy must be either \"reject\" or \"drop_oldest\"")
+}
+
+func TestConfigValidateDropOldestWithoutQueueSizeShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		Queue: opentelemetrygithubactionsannotationsreceiver.QueueConfig{
+			OverflowPolicy: "drop_oldest",
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "queue.queue_size must be greater than 0 if queue.overflow_policy is \"drop_oldest\"")
+}
+
+func TestConfigValidateDedupWithoutTTLShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		Dedup: opentelemetrygithubactionsannotationsreceiver.DedupConfig{
+			Size: 10,
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "dedup.ttl must be greater than 0 if dedup.size is set")
+}
+
+func TestConfigValidateInvalidEnterpriseURLsShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token:     "fake-token",
+			UploadURL: "/uploads",
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "github_auth.base_url must be set if github_auth.upload_url is set; github_auth.upload_url must be an absolute http(s) URL. e.g. \"https://github.example.com\"")
+}
+
+func TestConfigValidateEnterpriseURLsShouldSucceed(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token:   "fake-token",
+			BaseURL: "https://github.example.com/api/v3/",
+		},
+	}
+	err := config.Validate()
+	assert.NoError(t, err)
+}
+
+func TestConfigValidateNegativeBatchSizeShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		BatchSize: -1,
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "batch_size must not be negative")
+}
+
+func TestConfigValidateInvalidBackfillShouldFail(t *testing.T) {
+	storageID := component.MustNewID("file_storage")
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		StorageID: &storageID,
+		Backfill: opentelemetrygithubactionsannotationsreceiver.BackfillConfig{
+			Repositories:          []string{"owner/repo", "repo"},
+			Until:                 time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			MinRateLimitRemaining: -1,
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "backfill.repositories must be in the \"owner/name\" form, got \"repo\"; backfill.since must be set if backfill.repositories is set; backfill.min_rate_limit_remaining must not be negative")
+}
+
+func TestConfigValidateInvalidPollingShouldFail(t *testing.T) {
+	storageID := component.MustNewID("file_storage")
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		StorageID: &storageID,
+		Polling: opentelemetrygithubactionsannotationsreceiver.PollingConfig{
+			Repositories:  []string{"owner/repo/extra"},
+			Organizations: []string{"acme/repo"},
+			Lookback:      -time.Hour,
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "polling.repositories must be in the \"owner/name\" form, got \"owner/repo/extra\"; polling.organizations must be organization logins, got \"acme/repo\"; polling.interval must be greater than 0; polling.lookback must not be negative")
+}
+
+func TestConfigValidateBackfillWithoutStorageShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		Backfill: opentelemetrygithubactionsannotationsreceiver.BackfillConfig{
+			Repositories: []string{"owner/repo"},
+			Since:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "storage must be set if backfill.repositories is set, to checkpoint the backfill progress")
+}
+
+func TestConfigValidatePollingWithoutStorageShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		Polling: opentelemetrygithubactionsannotationsreceiver.PollingConfig{
+			Repositories: []string{"owner/repo"},
+			Interval:     time.Minute,
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "storage must be set if polling is enabled, to checkpoint the last poll")
+}
+
+func TestConfigValidateNegativeRateLimitReserveShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		RateLimit: opentelemetrygithubactionsannotationsreceiver.RateLimitConfig{
+			Reserve: -1,
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "rate_limit.reserve must not be negative")
+}
+
+func TestConfigValidateNegativeFetchTimeoutShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		FetchTimeout: -time.Second,
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "fetch_timeout must not be negative")
+}
+
+func TestConfigValidateNegativeRequeueDelayShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		Retry: opentelemetrygithubactionsannotationsreceiver.RetryConfig{
+			RequeueDelay: -time.Second,
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "retry.requeue_delay must not be negative")
+}
+
+func TestConfigValidateRequeueDelayWithoutMaxRequeuesShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		Retry: opentelemetrygithubactionsannotationsreceiver.RetryConfig{
+			RequeueDelay: time.Second,
+		},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "retry.max_requeues must be positive when retry.requeue_delay is set")
+}
+
+func TestConfigValidateInvalidFilterPatternShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		Filters: opentelemetrygithubactionsannotationsreceiver.FiltersConfig{
+			Workflows: opentelemetrygithubactionsannotationsreceiver.FilterConfig{
+				Include: []string{"regex:[a-"},
+			},
+		},
+	}
+	err := config.Validate()
+	assert.ErrorContains(t, err, "filters.workflows.include: invalid regular expression \"[a-\"")
+}
+
+func TestConfigValidateEmptyWebhookSecretFileShouldFail(t *testing.T) {
+	config := &opentelemetrygithubactionsannotationsreceiver.Config{
+		GitHubAuth: opentelemetrygithubactionsannotationsreceiver.GitHubAuth{
+			Token: "fake-token",
+		},
+		WebhookSecretFiles: []string{""},
+	}
+	err := config.Validate()
+	assert.EqualError(t, err, "webhook_secret_files must not contain empty paths")
 }
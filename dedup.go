@@ -0,0 +1,107 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// dedupCache remembers recently accepted webhooks to suppress redeliveries.
+// Entries expire after ttl and the oldest entries are evicted once size is reached.
+// A nil dedupCache accepts everything.
+type dedupCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]*list.Element
+	// order holds the entries from the oldest to the newest, which is also their expiration order
+	order *list.List
+	now   func() time.Time
+}
+
+type dedupEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+func newDedupCache(cfg DedupConfig) *dedupCache {
+	if cfg.Size == 0 {
+		return nil
+	}
+	return &dedupCache{
+		ttl:     cfg.TTL,
+		size:    cfg.Size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func deliveryDedupKey(deliveryID string) string {
+	return fmt.Sprintf("delivery:%s", deliveryID)
+}
+
+func jobDedupKey(jobID int64, runAttempt int64) string {
+	return fmt.Sprintf("job:%d:%d", jobID, runAttempt)
+}
+
+func workflowRunDedupKey(runID int64, runAttempt int64) string {
+	return fmt.Sprintf("workflow_run:%d:%d", runID, runAttempt)
+}
+
+func checkRunDedupKey(checkRunID int64) string {
+	return fmt.Sprintf("check_run:%d", checkRunID)
+}
+
+// addIfAbsent records all the keys and returns true, unless one of them
+// was already recorded, in which case nothing is recorded and false is returned.
+func (c *dedupCache) addIfAbsent(keys ...string) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.evictExpired(now)
+	for _, key := range keys {
+		if _, ok := c.entries[key]; ok {
+			return false
+		}
+	}
+	for _, key := range keys {
+		c.entries[key] = c.order.PushBack(&dedupEntry{key: key, expiresAt: now.Add(c.ttl)})
+	}
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Front())
+	}
+	return true
+}
+
+// remove forgets the keys so that a later delivery of the same webhook is processed again
+func (c *dedupCache) remove(keys ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.removeElement(element)
+		}
+	}
+}
+
+func (c *dedupCache) evictExpired(now time.Time) {
+	for element := c.order.Front(); element != nil; element = c.order.Front() {
+		if element.Value.(*dedupEntry).expiresAt.After(now) {
+			return
+		}
+		c.removeElement(element)
+	}
+}
+
+func (c *dedupCache) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*dedupEntry).key)
+}
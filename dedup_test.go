@@ -0,0 +1,58 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupCacheSuppressesDuplicateKeys(t *testing.T) {
+	cache := newDedupCache(DedupConfig{TTL: time.Hour, Size: 10})
+
+	assert.True(t, cache.addIfAbsent(deliveryDedupKey("a"), jobDedupKey(1, 1)))
+	assert.False(t, cache.addIfAbsent(deliveryDedupKey("a")))
+	assert.False(t, cache.addIfAbsent(deliveryDedupKey("b"), jobDedupKey(1, 1)))
+	assert.True(t, cache.addIfAbsent(deliveryDedupKey("b"), jobDedupKey(1, 2)))
+}
+
+func TestDedupCacheExpiresEntries(t *testing.T) {
+	// arrange
+	now := time.Now()
+	cache := newDedupCache(DedupConfig{TTL: time.Minute, Size: 10})
+	cache.now = func() time.Time { return now }
+	cache.addIfAbsent(jobDedupKey(1, 1))
+
+	// act
+	now = now.Add(time.Minute)
+
+	// assert
+	assert.True(t, cache.addIfAbsent(jobDedupKey(1, 1)))
+}
+
+func TestDedupCacheEvictsOldestEntries(t *testing.T) {
+	cache := newDedupCache(DedupConfig{TTL: time.Hour, Size: 2})
+
+	cache.addIfAbsent(jobDedupKey(1, 1))
+	cache.addIfAbsent(jobDedupKey(2, 1))
+	cache.addIfAbsent(jobDedupKey(3, 1))
+
+	assert.True(t, cache.addIfAbsent(jobDedupKey(1, 1)))
+	assert.False(t, cache.addIfAbsent(jobDedupKey(3, 1)))
+}
+
+func TestDedupCacheRemove(t *testing.T) {
+	cache := newDedupCache(DedupConfig{TTL: time.Hour, Size: 10})
+	cache.addIfAbsent(deliveryDedupKey("a"), jobDedupKey(1, 1))
+
+	cache.remove(deliveryDedupKey("a"), jobDedupKey(1, 1))
+
+	assert.True(t, cache.addIfAbsent(deliveryDedupKey("a"), jobDedupKey(1, 1)))
+}
+
+func TestDedupCacheDisabled(t *testing.T) {
+	cache := newDedupCache(DedupConfig{})
+
+	assert.True(t, cache.addIfAbsent(jobDedupKey(1, 1)))
+	assert.True(t, cache.addIfAbsent(jobDedupKey(1, 1)))
+}
@@ -3,6 +3,7 @@ package opentelemetrygithubactionsannotationsreceiver
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/confighttp"
@@ -20,27 +21,46 @@ func createDefaultConfig() component.Config {
 			InitialInterval: defaultRetryInitialInterval,
 			MaxInterval:     defaultRetryMaxInterval,
 			MaxElapsedTime:  defaultRetryMaxElapsedTime,
+			RequeueDelay:    defaultRetryRequeueDelay,
+			MaxRequeues:     defaultRetryMaxRequeues,
+		},
+		RateLimit: RateLimitConfig{
+			Reserve: defaultRateLimitReserve,
+		},
+		Queue: QueueConfig{
+			NumWorkers:     defaultQueueNumWorkers,
+			QueueSize:      defaultQueueSize,
+			OverflowPolicy: overflowPolicyReject,
+		},
+		Dedup: DedupConfig{
+			TTL:  defaultDedupTTL,
+			Size: defaultDedupSize,
+		},
+		Backfill: BackfillConfig{
+			MinRateLimitRemaining: defaultBackfillMinRemaining,
+		},
+		Polling: PollingConfig{
+			Interval:              defaultPollingInterval,
+			Lookback:              defaultPollingLookback,
+			MinRateLimitRemaining: defaultPollingMinRemaining,
 		},
 		BatchSize: 10000,
+		Severity: SeverityConfig{
+			Notice:  defaultNoticeSeverity,
+			Warning: defaultWarningSeverity,
+			Failure: defaultFailureSeverity,
+		},
 	}
 }
 
-func createTracerReceiver(
-	_ context.Context,
-	params receiver.CreateSettings,
-	rConf component.Config,
-	nextConsumer consumer.Logs,
-) (receiver.Logs, error) {
-	cfg := rConf.(*Config)
-	return newLogsReceiver(cfg, params, nextConsumer)
-}
-
 // NewFactory creates a factory for githubactionsannotationsreceiver.
 func NewFactory() receiver.Factory {
 	return receiver.NewFactory(
 		component.MustNewType("githubactionsannotations"),
 		createDefaultConfig,
 		receiver.WithLogs(createLogsReceiver, component.StabilityLevelAlpha),
+		receiver.WithTraces(createTracesReceiver, component.StabilityLevelAlpha),
+		receiver.WithMetrics(createMetricsReceiver, component.StabilityLevelAlpha),
 	)
 }
 
@@ -51,5 +71,67 @@ func createLogsReceiver(
 	consumer consumer.Logs,
 ) (receiver.Logs, error) {
 	cfg := rConf.(*Config)
-	return newLogsReceiver(cfg, params, consumer)
+	rec, err := getOrCreateReceiver(cfg, params)
+	if err != nil {
+		return nil, err
+	}
+	rec.logsConsumer = consumer
+	return rec, nil
+}
+
+func createTracesReceiver(
+	_ context.Context,
+	params receiver.CreateSettings,
+	rConf component.Config,
+	consumer consumer.Traces,
+) (receiver.Traces, error) {
+	cfg := rConf.(*Config)
+	rec, err := getOrCreateReceiver(cfg, params)
+	if err != nil {
+		return nil, err
+	}
+	rec.tracesConsumer = consumer
+	return rec, nil
+}
+
+func createMetricsReceiver(
+	_ context.Context,
+	params receiver.CreateSettings,
+	rConf component.Config,
+	consumer consumer.Metrics,
+) (receiver.Metrics, error) {
+	cfg := rConf.(*Config)
+	rec, err := getOrCreateReceiver(cfg, params)
+	if err != nil {
+		return nil, err
+	}
+	rec.metricsConsumer = consumer
+	return rec, nil
+}
+
+var (
+	receiversMu sync.Mutex
+	// receivers holds the receiver created for each configuration, so that the
+	// logs, traces and metrics pipelines share the same webhook endpoint
+	receivers = map[*Config]*githubactionsannotationsreceiver{}
+)
+
+func getOrCreateReceiver(cfg *Config, params receiver.CreateSettings) (*githubactionsannotationsreceiver, error) {
+	receiversMu.Lock()
+	defer receiversMu.Unlock()
+	if rec, ok := receivers[cfg]; ok {
+		return rec, nil
+	}
+	rec, err := newReceiver(cfg, params)
+	if err != nil {
+		return nil, err
+	}
+	receivers[cfg] = rec
+	return rec, nil
+}
+
+func removeReceiver(cfg *Config) {
+	receiversMu.Lock()
+	defer receiversMu.Unlock()
+	delete(receivers, cfg)
 }
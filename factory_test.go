@@ -0,0 +1,49 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+func TestFactorySharesReceiverBetweenSignals(t *testing.T) {
+	// arrange
+	ghServer := newGitHubTestServer(t)
+	cfg := newTestConfig(t)
+	cfg.GitHubAuth.BaseURL = ghServer.URL
+	factory := NewFactory()
+	logsSink := new(consumertest.LogsSink)
+	tracesSink := new(consumertest.TracesSink)
+	metricsSink := new(consumertest.MetricsSink)
+
+	// act
+	logsReceiver, err := factory.CreateLogsReceiver(context.Background(), receivertest.NewNopCreateSettings(), cfg, logsSink)
+	require.NoError(t, err)
+	tracesReceiver, err := factory.CreateTracesReceiver(context.Background(), receivertest.NewNopCreateSettings(), cfg, tracesSink)
+	require.NoError(t, err)
+	metricsReceiver, err := factory.CreateMetricsReceiver(context.Background(), receivertest.NewNopCreateSettings(), cfg, metricsSink)
+	require.NoError(t, err)
+
+	// assert
+	assert.Same(t, logsReceiver, tracesReceiver)
+	assert.Same(t, logsReceiver, metricsReceiver)
+	require.NoError(t, logsReceiver.Start(context.Background(), componenttest.NewNopHost()))
+	require.NoError(t, tracesReceiver.Start(context.Background(), componenttest.NewNopHost()))
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+	require.NoError(t, logsReceiver.Shutdown(context.Background()))
+	require.NoError(t, tracesReceiver.Shutdown(context.Background()))
+	require.NoError(t, metricsReceiver.Shutdown(context.Background()))
+	assert.Equal(t, 1, logsSink.LogRecordCount())
+	require.Equal(t, 1, tracesSink.SpanCount())
+	span := tracesSink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, "build", span.Name())
+	assert.Equal(t, 1, span.Events().Len())
+	require.Len(t, metricsSink.AllMetrics(), 1)
+	assert.Equal(t, "github.annotations", metricsSink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
+}
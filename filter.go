@@ -0,0 +1,254 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/multierr"
+)
+
+// jobConclusions are the conclusions of a completed workflow job
+var jobConclusions = []string{"success", "failure", "neutral", "cancelled", "skipped", "timed_out", "action_required", "stale"}
+
+// regexPatternPrefix marks the filter patterns that are regular expressions instead of globs
+const regexPatternPrefix = "regex:"
+
+// FilterConfig selects values with glob patterns, as supported by path.Match, or regular expressions
+// prefixed with "regex:". A value is selected if it matches one of the include patterns, or if there
+// are none, and none of the exclude patterns.
+type FilterConfig struct {
+	Include []string `mapstructure:"include"`
+	Exclude []string `mapstructure:"exclude"`
+}
+
+// FiltersConfig limits the workflow jobs and check runs whose annotations are collected and the annotations
+// emitted. The job filters are evaluated before any GitHub API call, so the skipped jobs do not use the rate
+// limit. A check run is filtered like a job named after it, on the head branch of its check suite, except
+// that the workflows filter does not apply since a check run has no workflow.
+type FiltersConfig struct {
+	// Repositories filters on the "owner/name" full name of the repository
+	Repositories FilterConfig `mapstructure:"repositories"`
+	// Owners filters on the organization or user owning the repository
+	Owners FilterConfig `mapstructure:"owners"`
+	// Branches filters on the head branch of the workflow run, or of the check suite of a check run
+	Branches FilterConfig `mapstructure:"branches"`
+	// Workflows filters on the workflow name
+	Workflows FilterConfig `mapstructure:"workflows"`
+	// Jobs filters on the job name, or on the check run name
+	Jobs FilterConfig `mapstructure:"jobs"`
+	// Conclusions is the allow-list of the job conclusions, e.g. failure, cancelled or timed_out. Empty allows all of them.
+	// The workflow runs are not filtered on their conclusion, since a successful run may have failed jobs.
+	Conclusions []string `mapstructure:"conclusions"`
+	// Annotations drops annotations before they are emitted as log records, span events and metrics
+	Annotations AnnotationFiltersConfig `mapstructure:"annotations"`
+}
+
+// AnnotationFiltersConfig drops the annotations that are not relevant, to reduce the noise
+type AnnotationFiltersConfig struct {
+	// MinLevel is the lowest annotation level emitted, one of notice, warning or failure. Empty emits all levels.
+	MinLevel string `mapstructure:"min_level"`
+	// Paths filters on the path of the annotated file
+	Paths FilterConfig `mapstructure:"paths"`
+	// Messages filters on the annotation message. Its patterns are regular expressions, without the "regex:" prefix.
+	Messages FilterConfig `mapstructure:"messages"`
+}
+
+func (cfg *FiltersConfig) validate() error {
+	_, err := newJobFilter(*cfg)
+	_, annotationErr := newAnnotationFilter(cfg.Annotations)
+	return multierr.Append(err, annotationErr)
+}
+
+type patternMatcher func(value string) bool
+
+// valueFilter is a compiled FilterConfig
+type valueFilter struct {
+	include []patternMatcher
+	exclude []patternMatcher
+}
+
+func newValueFilter(name string, cfg FilterConfig) (valueFilter, error) {
+	return compileValueFilter(name, cfg, newPatternMatcher)
+}
+
+func compileValueFilter(name string, cfg FilterConfig, compile func(pattern string) (patternMatcher, error)) (valueFilter, error) {
+	var filter valueFilter
+	var errs error
+	for _, pattern := range cfg.Include {
+		matcher, err := compile(pattern)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("filters.%s.include: %w", name, err))
+		}
+		filter.include = append(filter.include, matcher)
+	}
+	for _, pattern := range cfg.Exclude {
+		matcher, err := compile(pattern)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("filters.%s.exclude: %w", name, err))
+		}
+		filter.exclude = append(filter.exclude, matcher)
+	}
+	return filter, errs
+}
+
+func newPatternMatcher(pattern string) (patternMatcher, error) {
+	if expr, ok := strings.CutPrefix(pattern, regexPatternPrefix); ok {
+		return newRegexMatcher(expr)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+	return func(value string) bool {
+		matched, _ := path.Match(pattern, value)
+		return matched
+	}, nil
+}
+
+func newRegexMatcher(expr string) (patternMatcher, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", expr, err)
+	}
+	return re.MatchString, nil
+}
+
+func (f valueFilter) allows(value string) bool {
+	included := len(f.include) == 0
+	for _, match := range f.include {
+		if match(value) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, match := range f.exclude {
+		if match(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// jobFilter is a compiled FiltersConfig. A nil jobFilter allows everything.
+type jobFilter struct {
+	repositories valueFilter
+	owners       valueFilter
+	branches     valueFilter
+	workflows    valueFilter
+	jobs         valueFilter
+	conclusions  []string
+}
+
+func newJobFilter(cfg FiltersConfig) (*jobFilter, error) {
+	var filter jobFilter
+	var err, errs error
+	filter.repositories, err = newValueFilter("repositories", cfg.Repositories)
+	errs = multierr.Append(errs, err)
+	filter.owners, err = newValueFilter("owners", cfg.Owners)
+	errs = multierr.Append(errs, err)
+	filter.branches, err = newValueFilter("branches", cfg.Branches)
+	errs = multierr.Append(errs, err)
+	filter.workflows, err = newValueFilter("workflows", cfg.Workflows)
+	errs = multierr.Append(errs, err)
+	filter.jobs, err = newValueFilter("jobs", cfg.Jobs)
+	errs = multierr.Append(errs, err)
+	for _, conclusion := range cfg.Conclusions {
+		if !slices.Contains(jobConclusions, conclusion) {
+			errs = multierr.Append(errs, fmt.Errorf("filters.conclusions must be one of %s, got %q", strings.Join(jobConclusions, ", "), conclusion))
+		}
+	}
+	filter.conclusions = cfg.Conclusions
+	if errs != nil {
+		return nil, errs
+	}
+	return &filter, nil
+}
+
+// allowsRepository applies the repository and owner filters
+func (f *jobFilter) allowsRepository(repo *github.Repository) bool {
+	if f == nil {
+		return true
+	}
+	return f.repositories.allows(repo.GetFullName()) && f.owners.allows(repo.GetOwner().GetLogin())
+}
+
+// allowsRun applies the filters known before listing the jobs of a workflow run
+func (f *jobFilter) allowsRun(repo *github.Repository, run *github.WorkflowRun) bool {
+	if f == nil {
+		return true
+	}
+	return f.allowsRepository(repo) && f.branches.allows(run.GetHeadBranch()) && f.workflows.allows(run.GetName())
+}
+
+// allowsJob applies all the filters
+func (f *jobFilter) allowsJob(repo *github.Repository, job *github.WorkflowJob) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.conclusions) > 0 && !slices.Contains(f.conclusions, job.GetConclusion()) {
+		return false
+	}
+	return f.allowsRepository(repo) && f.branches.allows(job.GetHeadBranch()) && f.workflows.allows(job.GetWorkflowName()) && f.jobs.allows(job.GetName())
+}
+
+// allowsCheckRun applies the filters to a check run, except the workflows filter
+func (f *jobFilter) allowsCheckRun(repo *github.Repository, checkRun *github.CheckRun) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.conclusions) > 0 && !slices.Contains(f.conclusions, checkRun.GetConclusion()) {
+		return false
+	}
+	return f.allowsRepository(repo) && f.branches.allows(checkRun.GetCheckSuite().GetHeadBranch()) && f.jobs.allows(checkRun.GetName())
+}
+
+// annotationLevels orders the annotation levels from the least to the most severe
+var annotationLevels = map[string]int{
+	annotationLevelNotice:  1,
+	annotationLevelWarning: 2,
+	annotationLevelFailure: 3,
+}
+
+// annotationFilter is a compiled AnnotationFiltersConfig. A nil annotationFilter allows everything.
+type annotationFilter struct {
+	minLevel int
+	paths    valueFilter
+	messages valueFilter
+}
+
+func newAnnotationFilter(cfg AnnotationFiltersConfig) (*annotationFilter, error) {
+	var filter annotationFilter
+	var err, errs error
+	if cfg.MinLevel != "" {
+		var ok bool
+		filter.minLevel, ok = annotationLevels[strings.ToLower(cfg.MinLevel)]
+		if !ok {
+			errs = multierr.Append(errs, fmt.Errorf("filters.annotations.min_level must be one of notice, warning or failure, got %q", cfg.MinLevel))
+		}
+	}
+	filter.paths, err = newValueFilter("annotations.paths", cfg.Paths)
+	errs = multierr.Append(errs, err)
+	filter.messages, err = compileValueFilter("annotations.messages", cfg.Messages, newRegexMatcher)
+	errs = multierr.Append(errs, err)
+	if errs != nil {
+		return nil, errs
+	}
+	return &filter, nil
+}
+
+// allows returns whether the annotation is emitted. The annotations of an unknown level pass the min_level filter.
+func (f *annotationFilter) allows(annotation *checkRunAnnotation) bool {
+	if f == nil {
+		return true
+	}
+	if level, ok := annotationLevels[strings.ToLower(annotation.GetAnnotationLevel())]; ok && level < f.minLevel {
+		return false
+	}
+	return f.paths.allows(annotation.GetPath()) && f.messages.allows(annotation.GetMessage())
+}
@@ -0,0 +1,126 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     FilterConfig
+		value   string
+		allowed bool
+	}{
+		{"no patterns", FilterConfig{}, "main", true},
+		{"included by glob", FilterConfig{Include: []string{"release/*"}}, "release/1.0", true},
+		{"not included", FilterConfig{Include: []string{"release/*"}}, "main", false},
+		{"excluded by glob", FilterConfig{Exclude: []string{"dependabot/*"}}, "dependabot/go", false},
+		{"included by regex", FilterConfig{Include: []string{"regex:^(main|master)$"}}, "master", true},
+		{"excluded by regex", FilterConfig{Include: []string{"*"}, Exclude: []string{"regex:^renovate/"}}, "renovate/deps", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newValueFilter("branches", tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.allowed, filter.allows(tt.value))
+		})
+	}
+}
+
+func TestJobFilterAllowsJob(t *testing.T) {
+	// arrange
+	filter, err := newJobFilter(FiltersConfig{
+		Owners:    FilterConfig{Include: []string{"owner"}},
+		Workflows: FilterConfig{Include: []string{"CI"}},
+		Jobs:      FilterConfig{Exclude: []string{"lint*"}},
+	})
+	require.NoError(t, err)
+	event := newWorkflowJobEvent(1)
+	lintJob := newWorkflowJobEvent(2).GetWorkflowJob()
+	lintJob.Name = github.String("lint-go")
+	otherOwner := &github.Repository{FullName: github.String("other/repo"), Owner: &github.User{Login: github.String("other")}}
+
+	// act & assert
+	assert.True(t, filter.allowsJob(event.GetRepo(), event.GetWorkflowJob()))
+	assert.False(t, filter.allowsJob(event.GetRepo(), lintJob))
+	assert.False(t, filter.allowsJob(otherOwner, event.GetWorkflowJob()))
+}
+
+func TestJobFilterAllowsCheckRun(t *testing.T) {
+	// arrange
+	filter, err := newJobFilter(FiltersConfig{
+		Branches:  FilterConfig{Include: []string{"main"}},
+		Workflows: FilterConfig{Include: []string{"CI"}},
+		Jobs:      FilterConfig{Exclude: []string{"Sonar*"}},
+	})
+	require.NoError(t, err)
+	event := newCheckRunEvent(1, "github-advanced-security")
+	event.CheckRun.CheckSuite = &github.CheckSuite{HeadBranch: github.String("main")}
+	otherBranch := newCheckRunEvent(2, "github-advanced-security").GetCheckRun()
+	otherBranch.CheckSuite = &github.CheckSuite{HeadBranch: github.String("feature")}
+	excluded := newCheckRunEvent(3, "sonarcloud").GetCheckRun()
+	excluded.Name = github.String("SonarCloud Code Analysis")
+	excluded.CheckSuite = &github.CheckSuite{HeadBranch: github.String("main")}
+
+	// act & assert
+	assert.True(t, filter.allowsCheckRun(event.GetRepo(), event.GetCheckRun()))
+	assert.False(t, filter.allowsCheckRun(event.GetRepo(), otherBranch))
+	assert.False(t, filter.allowsCheckRun(event.GetRepo(), excluded))
+}
+
+func TestNewJobFilterInvalidPatterns(t *testing.T) {
+	_, err := newJobFilter(FiltersConfig{
+		Repositories: FilterConfig{Include: []string{"owner/["}},
+		Jobs:         FilterConfig{Exclude: []string{"regex:("}},
+	})
+
+	assert.EqualError(t, err, "filters.repositories.include: invalid glob pattern \"owner/[\": syntax error in pattern; filters.jobs.exclude: invalid regular expression \"(\": error parsing regexp: missing closing ): `(`")
+}
+
+func TestAnnotationFilterAllows(t *testing.T) {
+	// arrange
+	filter, err := newAnnotationFilter(AnnotationFiltersConfig{
+		MinLevel: "warning",
+		Paths:    FilterConfig{Exclude: []string{"vendor/*"}},
+		Messages: FilterConfig{Exclude: []string{"(?i)deprecated"}},
+	})
+	require.NoError(t, err)
+	deprecation := newTestAnnotation("main.go", "warning")
+	deprecation.Message = github.String("Node.js 16 actions are Deprecated")
+
+	// act & assert
+	assert.True(t, filter.allows(newTestAnnotation("main.go", "failure")))
+	assert.True(t, filter.allows(newTestAnnotation("main.go", "unknown")))
+	assert.False(t, filter.allows(newTestAnnotation("main.go", "notice")))
+	assert.False(t, filter.allows(newTestAnnotation("vendor/lib.go", "failure")))
+	assert.False(t, filter.allows(deprecation))
+}
+
+func TestNewAnnotationFilterInvalidMinLevel(t *testing.T) {
+	_, err := newAnnotationFilter(AnnotationFiltersConfig{MinLevel: "error"})
+
+	assert.EqualError(t, err, "filters.annotations.min_level must be one of notice, warning or failure, got \"error\"")
+}
+
+func TestJobFilterConclusions(t *testing.T) {
+	// arrange
+	filter, err := newJobFilter(FiltersConfig{Conclusions: []string{"failure", "timed_out"}})
+	require.NoError(t, err)
+	failed := newWorkflowJobEvent(1)
+	succeeded := newWorkflowJobEvent(2)
+	succeeded.WorkflowJob.Conclusion = github.String("success")
+
+	// act & assert
+	assert.True(t, filter.allowsJob(failed.GetRepo(), failed.GetWorkflowJob()))
+	assert.False(t, filter.allowsJob(succeeded.GetRepo(), succeeded.GetWorkflowJob()))
+}
+
+func TestNewJobFilterUnknownConclusion(t *testing.T) {
+	_, err := newJobFilter(FiltersConfig{Conclusions: []string{"failed"}})
+
+	assert.EqualError(t, err, "filters.conclusions must be one of success, failure, neutral, cancelled, skipped, timed_out, action_required, stale, got \"failed\"")
+}
@@ -1,44 +1,126 @@
 package opentelemetrygithubactionsannotationsreceiver
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v66/github"
 )
 
-func createGitHubClient(githubAuth GitHubAuth) (*github.Client, error) {
+// createGitHubClient creates a client authenticated with the token or as the GitHub App installation.
+// The API requests go through rateLimiter, if any.
+func createGitHubClient(githubAuth GitHubAuth, rateLimiter *rateLimitTransport) (*github.Client, error) {
 	if githubAuth.AppID != 0 {
-		if githubAuth.PrivateKey != "" {
-			var privateKey []byte
-			privateKey, err := base64.StdEncoding.DecodeString(string(githubAuth.PrivateKey))
-			if err != nil {
-				privateKey = []byte(githubAuth.PrivateKey)
-			}
-			itr, err := ghinstallation.New(
-				http.DefaultTransport,
-				githubAuth.AppID,
-				githubAuth.InstallationID,
-				privateKey,
-			)
-			if err != nil {
-				return &github.Client{}, err
-			}
-			return github.NewClient(&http.Client{Transport: itr}), nil
-		} else {
-			itr, err := ghinstallation.NewKeyFromFile(
-				http.DefaultTransport,
-				githubAuth.AppID,
-				githubAuth.InstallationID,
-				githubAuth.PrivateKeyPath,
-			)
-			if err != nil {
-				return &github.Client{}, err
-			}
-			return github.NewClient(&http.Client{Transport: itr}), nil
+		atr, err := newAppsTransport(githubAuth)
+		if err != nil {
+			return &github.Client{}, err
 		}
+		return newInstallationClient(atr, githubAuth, githubAuth.InstallationID, rateLimiter)
 	} else {
-		return github.NewClient(nil).WithAuthToken(string(githubAuth.Token)), nil
+		httpClient := &http.Client{Transport: rateLimiter.wrap(http.DefaultTransport)}
+		return withEnterpriseURLs(github.NewClient(httpClient).WithAuthToken(string(githubAuth.Token)), githubAuth)
 	}
 }
+
+// newAppsTransport creates the transport authenticated as the GitHub App, from which the installation
+// transports are created
+func newAppsTransport(githubAuth GitHubAuth) (*ghinstallation.AppsTransport, error) {
+	if githubAuth.PrivateKey != "" {
+		privateKey, err := base64.StdEncoding.DecodeString(string(githubAuth.PrivateKey))
+		if err != nil {
+			privateKey = []byte(githubAuth.PrivateKey)
+		}
+		return ghinstallation.NewAppsTransport(http.DefaultTransport, githubAuth.AppID, privateKey)
+	}
+	return ghinstallation.NewAppsTransportKeyFromFile(http.DefaultTransport, githubAuth.AppID, githubAuth.PrivateKeyPath)
+}
+
+// newInstallationClient creates a client authenticated as the GitHub App installation. Its installation
+// token is refreshed before it expires. The API requests go through rateLimiter, if any.
+func newInstallationClient(atr *ghinstallation.AppsTransport, githubAuth GitHubAuth, installationID int64, rateLimiter *rateLimitTransport) (*github.Client, error) {
+	itr := ghinstallation.NewFromAppsTransport(atr, installationID)
+	client, err := withEnterpriseURLs(github.NewClient(&http.Client{Transport: rateLimiter.wrap(itr)}), githubAuth)
+	if err != nil {
+		return &github.Client{}, err
+	}
+	// installation tokens are requested from the same API as the one used by the client
+	itr.BaseURL = strings.TrimSuffix(client.BaseURL.String(), "/")
+	return client, nil
+}
+
+// errGitHubRetriesExhausted wraps the error of a GitHub API call still failing once the retries are exhausted
+var errGitHubRetriesExhausted = errors.New("GitHub API retries exhausted")
+
+// isRetryableGitHubError returns whether a GitHub API call may succeed if retried: the server
+// and rate limit errors, the timeouts and the network errors are, the other HTTP errors are not
+func isRetryableGitHubError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rateLimitErr *github.RateLimitError
+	var abuseRateLimitErr *github.AbuseRateLimitError
+	if errors.As(err, &rateLimitErr) || errors.As(err, &abuseRateLimitErr) {
+		return true
+	}
+	var errorResponse *github.ErrorResponse
+	var tokenErr *ghinstallation.HTTPError
+	var statusCode int
+	switch {
+	case errors.As(err, &errorResponse) && errorResponse.Response != nil:
+		statusCode = errorResponse.Response.StatusCode
+	case errors.As(err, &tokenErr) && tokenErr.Response != nil:
+		// the installation token request failed
+		statusCode = tokenErr.Response.StatusCode
+	default:
+		return true
+	}
+	return statusCode >= http.StatusInternalServerError || statusCode == http.StatusTooManyRequests || statusCode == http.StatusRequestTimeout
+}
+
+// withEnterpriseURLs points the client to a GitHub Enterprise Server instance if a base URL is configured
+func withEnterpriseURLs(client *github.Client, githubAuth GitHubAuth) (*github.Client, error) {
+	if githubAuth.BaseURL == "" {
+		return client, nil
+	}
+	uploadURL := githubAuth.UploadURL
+	if uploadURL == "" {
+		uploadURL = githubAuth.BaseURL
+	}
+	return client.WithEnterpriseURLs(githubAuth.BaseURL, uploadURL)
+}
+
+// checkRunAnnotation extends github.CheckRunAnnotation with the blob_href
+// field that the API returns but go-github does not expose
+type checkRunAnnotation struct {
+	github.CheckRunAnnotation
+	BlobHRef *string `json:"blob_href,omitempty"`
+}
+
+// GetBlobHRef returns the BlobHRef field if it's non-nil, zero value otherwise.
+func (c *checkRunAnnotation) GetBlobHRef() string {
+	if c == nil || c.BlobHRef == nil {
+		return ""
+	}
+	return *c.BlobHRef
+}
+
+// listCheckRunAnnotations lists the annotations for a check run.
+// It mirrors ghClient.Checks.ListCheckRunAnnotations but decodes into checkRunAnnotation.
+func listCheckRunAnnotations(ctx context.Context, ghClient *github.Client, owner, repo string, checkRunID int64, opts *github.ListOptions) ([]*checkRunAnnotation, *github.Response, error) {
+	u := fmt.Sprintf("repos/%v/%v/check-runs/%v/annotations?per_page=%d&page=%d", owner, repo, checkRunID, opts.PerPage, opts.Page)
+	req, err := ghClient.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var annotations []*checkRunAnnotation
+	resp, err := ghClient.Do(ctx, req, &annotations)
+	if err != nil {
+		return nil, resp, err
+	}
+	return annotations, resp, nil
+}
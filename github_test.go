@@ -1,14 +1,21 @@
 package opentelemetrygithubactionsannotationsreceiver
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/google/go-github/v66/github"
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/collector/config/configopaque"
 )
@@ -20,7 +27,7 @@ func TestCreateGitHubClient(t *testing.T) {
 	}
 
 	// act
-	_, err := createGitHubClient(ghAuth)
+	_, err := createGitHubClient(ghAuth, nil)
 
 	// assert
 	assert.NoError(t, err)
@@ -55,7 +62,7 @@ func TestCreateGitHubClient2(t *testing.T) {
 	}
 
 	// act
-	_, err = createGitHubClient(ghAuth)
+	_, err = createGitHubClient(ghAuth, nil)
 
 	// assert
 	assert.NoError(t, err)
@@ -82,7 +89,7 @@ func TestCreateGitHubClient3(t *testing.T) {
 	}
 
 	// act
-	_, err = createGitHubClient(ghAuth)
+	_, err = createGitHubClient(ghAuth, nil)
 
 	// assert
 	assert.NoError(t, err)
@@ -97,8 +104,104 @@ func TestCreateGitHubClient4(t *testing.T) {
 	}
 
 	// act
-	_, err := createGitHubClient(ghAuth)
+	_, err := createGitHubClient(ghAuth, nil)
 
 	// assert
 	assert.EqualError(t, err, "could not parse private key: invalid key: Key must be a PEM encoded PKCS1 or PKCS8 key")
 }
+
+func TestListCheckRunAnnotations(t *testing.T) {
+	// arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/check-runs/42/annotations", r.URL.Path)
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+		fmt.Fprint(w, `[{"path":"main.go","start_line":1,"annotation_level":"failure","message":"boom","blob_href":"https://github.com/owner/repo/blob/abc/main.go"}]`)
+	}))
+	defer server.Close()
+	ghClient := github.NewClient(nil)
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+
+	// act
+	annotations, _, err := listCheckRunAnnotations(context.Background(), ghClient, "owner", "repo", 42, &github.ListOptions{PerPage: 100})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Len(t, annotations, 1)
+	assert.Equal(t, "main.go", annotations[0].GetPath())
+	assert.Equal(t, "failure", annotations[0].GetAnnotationLevel())
+	assert.Equal(t, "https://github.com/owner/repo/blob/abc/main.go", annotations[0].GetBlobHRef())
+}
+
+func TestCreateGitHubClientEnterpriseToken(t *testing.T) {
+	// arrange
+	ghAuth := GitHubAuth{
+		Token:   "token",
+		BaseURL: "https://github.example.com",
+	}
+
+	// act
+	client, err := createGitHubClient(ghAuth, nil)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, "https://github.example.com/api/v3/", client.BaseURL.String())
+	assert.Equal(t, "https://github.example.com/api/uploads/", client.UploadURL.String())
+}
+
+func TestCreateGitHubClientEnterpriseApp(t *testing.T) {
+	// arrange private key
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return
+	}
+	encodedPrivateKey := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+
+	// arrange receiver config
+	ghAuth := GitHubAuth{
+		AppID:          123,
+		InstallationID: 123,
+		PrivateKey:     configopaque.String(encodedPrivateKey),
+		BaseURL:        "https://github.example.com/api/v3/",
+		UploadURL:      "https://uploads.github.example.com/",
+	}
+
+	// act
+	client, err := createGitHubClient(ghAuth, nil)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, "https://github.example.com/api/v3/", client.BaseURL.String())
+	assert.Equal(t, "https://uploads.github.example.com/api/uploads/", client.UploadURL.String())
+	itr, ok := client.Client().Transport.(*ghinstallation.Transport)
+	assert.True(t, ok)
+	assert.Equal(t, "https://github.example.com/api/v3", itr.BaseURL)
+}
+
+func TestIsRetryableGitHubError(t *testing.T) {
+	errorResponse := func(statusCode int) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: statusCode, Request: &http.Request{Method: http.MethodGet, URL: &url.URL{}}}}
+	}
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"server error", errorResponse(http.StatusBadGateway), true},
+		{"too many requests", errorResponse(http.StatusTooManyRequests), true},
+		{"not found", errorResponse(http.StatusNotFound), false},
+		{"unauthorized", errorResponse(http.StatusUnauthorized), false},
+		{"rate limit", &github.RateLimitError{}, true},
+		{"installation token not found", &url.Error{Op: "Get", URL: "https://api.github.com", Err: &ghinstallation.HTTPError{Response: &http.Response{StatusCode: http.StatusNotFound}}}, false},
+		{"installation token server error", &ghinstallation.HTTPError{Response: &http.Response{StatusCode: http.StatusBadGateway}}, true},
+		{"network error", &url.Error{Op: "Get", URL: "https://api.github.com", Err: fmt.Errorf("connection refused")}, true},
+		{"cancelled", fmt.Errorf("failed: %w", context.Canceled), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.retryable, isRetryableGitHubError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/zap"
+)
+
+// errNoInstallation is returned when the GitHub App installation of a request cannot be determined
+var errNoInstallation = errors.New("the GitHub App installation is unknown, github_auth.installation_id is not set")
+
+// githubClients provides the GitHub API clients. With a token, a single client is used. As a GitHub App,
+// a client is created on first use for each installation and cached, so a single receiver serves all the
+// accounts where the app is installed. Each installation has its own rate limit, and so its own rate limit
+// transport.
+type githubClients struct {
+	auth      GitHubAuth
+	rateLimit RateLimitConfig
+	logger    *zap.Logger
+	// retry calls the installation lookups with the retries of the GitHub API calls, nil calls them once
+	retry func(ctx context.Context, withInfoFields func(fields ...zap.Field) []zap.Field, call func(ctx context.Context) error) error
+	// appsTransport and appClient authenticate as the GitHub App, they are nil with a token
+	appsTransport *ghinstallation.AppsTransport
+	appClient     *github.Client
+
+	mu sync.Mutex
+	// clients are the clients by installation ID, the token client has the ID 0
+	clients map[int64]*installationClient
+	// installationIDs are the installations found by account login, in lower case
+	installationIDs map[string]int64
+}
+
+type installationClient struct {
+	client      *github.Client
+	rateLimiter *rateLimitTransport
+}
+
+func newGitHubClients(githubAuth GitHubAuth, rateLimit RateLimitConfig, logger *zap.Logger) (*githubClients, error) {
+	clients := &githubClients{
+		auth:            githubAuth,
+		rateLimit:       rateLimit,
+		logger:          logger,
+		clients:         make(map[int64]*installationClient),
+		installationIDs: make(map[string]int64),
+	}
+	if githubAuth.AppID == 0 {
+		rateLimiter := newRateLimitTransport(rateLimit, logger)
+		client, err := createGitHubClient(githubAuth, rateLimiter)
+		if err != nil {
+			return nil, err
+		}
+		clients.clients[0] = &installationClient{client: client, rateLimiter: rateLimiter}
+		return clients, nil
+	}
+	atr, err := newAppsTransport(githubAuth)
+	if err != nil {
+		return nil, err
+	}
+	appClient, err := withEnterpriseURLs(github.NewClient(&http.Client{Transport: atr}), githubAuth)
+	if err != nil {
+		return nil, err
+	}
+	atr.BaseURL = strings.TrimSuffix(appClient.BaseURL.String(), "/")
+	clients.appsTransport = atr
+	clients.appClient = appClient
+	return clients, nil
+}
+
+// forInstallation returns the client of the installation, or of github_auth.installation_id if installationID is 0.
+// With a token, the installation is ignored.
+func (c *githubClients) forInstallation(installationID int64) (*github.Client, error) {
+	if c.appsTransport == nil {
+		return c.clients[0].client, nil
+	}
+	if installationID == 0 {
+		installationID = c.auth.InstallationID
+	}
+	if installationID == 0 {
+		return nil, errNoInstallation
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if installation, ok := c.clients[installationID]; ok {
+		return installation.client, nil
+	}
+	rateLimiter := newRateLimitTransport(c.rateLimit, c.logger.With(zap.Int64("github.installation.id", installationID)))
+	// the installation transport updates the base URL of its apps transport, each installation gets its own copy
+	atr := *c.appsTransport
+	client, err := newInstallationClient(&atr, c.auth, installationID, rateLimiter)
+	if err != nil {
+		return nil, err
+	}
+	c.clients[installationID] = &installationClient{client: client, rateLimiter: rateLimiter}
+	c.logger.Debug("Created GitHub App installation client", zap.Int64("github.installation.id", installationID))
+	return client, nil
+}
+
+// forEvent returns the client of the installation that sent the webhook. Without an installation,
+// e.g. for a repository webhook, it falls back to the client of the repository.
+func (c *githubClients) forEvent(ctx context.Context, installation *github.Installation, repo *github.Repository) (*github.Client, error) {
+	if installation.GetID() != 0 {
+		return c.forInstallation(installation.GetID())
+	}
+	return c.forRepository(ctx, repo)
+}
+
+// forRepository returns the client of github_auth.installation_id if set, and otherwise
+// the client of the installation on the account owning the repository
+func (c *githubClients) forRepository(ctx context.Context, repo *github.Repository) (*github.Client, error) {
+	return c.forAccount(ctx, repo.GetOwner().GetLogin(), func(ctx context.Context) (*github.Installation, error) {
+		installation, _, err := c.appClient.Apps.FindRepositoryInstallation(ctx, repo.GetOwner().GetLogin(), repo.GetName())
+		return installation, err
+	})
+}
+
+// forOrganization returns the client of github_auth.installation_id if set, and otherwise
+// the client of the installation on the organization
+func (c *githubClients) forOrganization(ctx context.Context, org string) (*github.Client, error) {
+	return c.forAccount(ctx, org, func(ctx context.Context) (*github.Installation, error) {
+		installation, _, err := c.appClient.Apps.FindOrganizationInstallation(ctx, org)
+		return installation, err
+	})
+}
+
+func (c *githubClients) forAccount(ctx context.Context, login string, findInstallation func(ctx context.Context) (*github.Installation, error)) (*github.Client, error) {
+	if c.appsTransport == nil || c.auth.InstallationID != 0 {
+		return c.forInstallation(0)
+	}
+	c.mu.Lock()
+	installationID, ok := c.installationIDs[strings.ToLower(login)]
+	c.mu.Unlock()
+	if !ok {
+		var installation *github.Installation
+		lookup := func(ctx context.Context) error {
+			var err error
+			installation, err = findInstallation(ctx)
+			return err
+		}
+		var err error
+		if c.retry != nil {
+			err = c.retry(ctx, func(fields ...zap.Field) []zap.Field {
+				return append([]zap.Field{zap.String("github.account", login)}, fields...)
+			}, lookup)
+		} else {
+			err = lookup(ctx)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the GitHub App installation of %q: %w", login, err)
+		}
+		installationID = installation.GetID()
+		c.mu.Lock()
+		c.installationIDs[strings.ToLower(login)] = installationID
+		c.mu.Unlock()
+	}
+	return c.forInstallation(installationID)
+}
+
+// evict forgets the installation found for the account if err shows that it is stale, e.g. once the app
+// was reinstalled, so that the next request looks it up again. It returns whether it was forgotten.
+func (c *githubClients) evict(login string, err error) bool {
+	if c == nil || !isStaleInstallationError(err) {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	installationID, ok := c.installationIDs[strings.ToLower(login)]
+	if !ok {
+		return false
+	}
+	delete(c.installationIDs, strings.ToLower(login))
+	c.logger.Warn("Forgetting the GitHub App installation of the account", zap.String("github.account", login), zap.Int64("github.installation.id", installationID), zap.Error(err))
+	return true
+}
+
+// retryStale calls call and, if it fails because the installation found for the account is stale, e.g. once
+// the app was reinstalled, calls it again so that the installation is looked up again
+func (c *githubClients) retryStale(login string, call func() error) error {
+	err := call()
+	if err != nil && c.evict(login, err) {
+		return call()
+	}
+	return err
+}
+
+// isStaleInstallationError returns whether the GitHub API or the installation token request answered
+// 401 or 404, as they do once the installation is removed
+func isStaleInstallationError(err error) bool {
+	var statusCode int
+	var errorResponse *github.ErrorResponse
+	var tokenErr *ghinstallation.HTTPError
+	switch {
+	case errors.As(err, &errorResponse) && errorResponse.Response != nil:
+		statusCode = errorResponse.Response.StatusCode
+	case errors.As(err, &tokenErr) && tokenErr.Response != nil:
+		statusCode = tokenErr.Response.StatusCode
+	}
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusNotFound
+}
+
+// rateLimitStates calls observe with the primary rate limit of the last response of each client
+func (c *githubClients) rateLimitStates(observe func(installationID int64, limit int, remaining int)) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for installationID, installation := range c.clients {
+		if limit, remaining, ok := installation.rateLimiter.state(); ok {
+			observe(installationID, limit, remaining)
+		}
+	}
+}
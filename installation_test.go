@@ -0,0 +1,310 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configopaque"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newGitHubAppTestServer serves installation tokens named after the installation ID, finds the installation 7
+// for every repository and answers the other requests with the token they are authenticated with
+func newGitHubAppTestServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	return newGitHubAppTestServerWithLookup(t, func(int32) (int, int64) { return http.StatusOK, 7 }, nil)
+}
+
+// newGitHubAppTestServerWithLookup is newGitHubAppTestServer where lookup answers the nth installation lookup
+// with a status code and an installation ID, the tokens of the uninstalled installations are refused with a 404
+// and the annotations are listed
+func newGitHubAppTestServerWithLookup(t *testing.T, lookup func(n int32) (int, int64), uninstalled func(installationID string) bool) (*httptest.Server, *atomic.Int32) {
+	var lookups atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/v3")
+		switch {
+		case strings.HasPrefix(path, "/app/installations/") && strings.HasSuffix(path, "/access_tokens"):
+			installationID := strings.TrimSuffix(strings.TrimPrefix(path, "/app/installations/"), "/access_tokens")
+			if uninstalled != nil && uninstalled(installationID) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token":"token-%s","expires_at":"%s"}`, installationID, time.Now().Add(time.Hour).Format(time.RFC3339))
+		case strings.HasSuffix(path, "/installation"):
+			statusCode, installationID := lookup(lookups.Add(1))
+			w.WriteHeader(statusCode)
+			fmt.Fprintf(w, `{"id":%d}`, installationID)
+		case strings.HasSuffix(path, "/annotations"):
+			fmt.Fprint(w, `[{"path":"main.go","start_line":1,"annotation_level":"failure","message":"boom"}]`)
+		default:
+			fmt.Fprintf(w, `{"message":%q}`, r.Header.Get("Authorization"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &lookups
+}
+
+func newTestGitHubAppAuth(t *testing.T, baseURL string) GitHubAuth {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return GitHubAuth{
+		AppID: 123,
+		PrivateKey: configopaque.String(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+		})),
+		BaseURL: baseURL,
+	}
+}
+
+// authorization returns the Authorization header of a request sent by the client
+func authorization(t *testing.T, client *github.Client) string {
+	req, err := client.NewRequest(http.MethodGet, "authorization", nil)
+	require.NoError(t, err)
+	var body struct {
+		Message string `json:"message"`
+	}
+	_, err = client.Do(context.Background(), req, &body)
+	require.NoError(t, err)
+	return body.Message
+}
+
+func TestGitHubClientsForInstallation(t *testing.T) {
+	// arrange
+	server, _ := newGitHubAppTestServer(t)
+	clients, err := newGitHubClients(newTestGitHubAppAuth(t, server.URL), RateLimitConfig{}, zap.NewNop())
+	require.NoError(t, err)
+
+	// act
+	first, err := clients.forInstallation(1)
+	require.NoError(t, err)
+	second, err := clients.forInstallation(2)
+	require.NoError(t, err)
+	cached, err := clients.forInstallation(1)
+	require.NoError(t, err)
+
+	// assert
+	assert.Same(t, first, cached)
+	assert.Equal(t, "token token-1", authorization(t, first))
+	assert.Equal(t, "token token-2", authorization(t, second))
+}
+
+func TestGitHubClientsForRepositoryFindsInstallation(t *testing.T) {
+	// arrange
+	server, lookups := newGitHubAppTestServer(t)
+	clients, err := newGitHubClients(newTestGitHubAppAuth(t, server.URL), RateLimitConfig{}, zap.NewNop())
+	require.NoError(t, err)
+
+	// act
+	client, err := clients.forRepository(context.Background(), newRepository("owner/repo"))
+	require.NoError(t, err)
+	_, err = clients.forRepository(context.Background(), newRepository("Owner/other"))
+	require.NoError(t, err)
+
+	// assert
+	assert.Equal(t, "token token-7", authorization(t, client))
+	assert.Equal(t, int32(1), lookups.Load())
+}
+
+func TestGitHubClientsForEvent(t *testing.T) {
+	// arrange
+	server, lookups := newGitHubAppTestServer(t)
+	auth := newTestGitHubAppAuth(t, server.URL)
+	auth.InstallationID = 3
+	clients, err := newGitHubClients(auth, RateLimitConfig{}, zap.NewNop())
+	require.NoError(t, err)
+
+	// act
+	fromInstallation, err := clients.forEvent(context.Background(), &github.Installation{ID: github.Int64(42)}, newRepository("owner/repo"))
+	require.NoError(t, err)
+	withoutInstallation, err := clients.forEvent(context.Background(), nil, newRepository("owner/repo"))
+	require.NoError(t, err)
+
+	// assert
+	assert.Equal(t, "token token-42", authorization(t, fromInstallation))
+	assert.Equal(t, "token token-3", authorization(t, withoutInstallation))
+	assert.Zero(t, lookups.Load())
+}
+
+func TestGitHubClientsWithoutInstallation(t *testing.T) {
+	server, _ := newGitHubAppTestServer(t)
+	clients, err := newGitHubClients(newTestGitHubAppAuth(t, server.URL), RateLimitConfig{}, zap.NewNop())
+	require.NoError(t, err)
+
+	_, err = clients.forInstallation(0)
+
+	assert.ErrorIs(t, err, errNoInstallation)
+}
+
+func TestGitHubClientsWithToken(t *testing.T) {
+	server, _ := newGitHubAppTestServer(t)
+	clients, err := newGitHubClients(GitHubAuth{Token: "token", BaseURL: server.URL}, RateLimitConfig{}, zap.NewNop())
+	require.NoError(t, err)
+
+	client, err := clients.forEvent(context.Background(), &github.Installation{ID: github.Int64(42)}, newRepository("owner/repo"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", authorization(t, client))
+}
+
+func TestGitHubClientsRetriesInstallationLookup(t *testing.T) {
+	// arrange
+	server, lookups := newGitHubAppTestServerWithLookup(t, func(n int32) (int, int64) {
+		if n == 1 {
+			return http.StatusServiceUnavailable, 0
+		}
+		return http.StatusOK, 7
+	}, nil)
+	clients, err := newGitHubClients(newTestGitHubAppAuth(t, server.URL), RateLimitConfig{}, zap.NewNop())
+	require.NoError(t, err)
+	rec := &githubactionsannotationsreceiver{
+		config: &Config{Retry: RetryConfig{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, MaxElapsedTime: time.Second}},
+		logger: zap.NewNop(),
+	}
+	clients.retry = rec.retryGitHubCall
+
+	// act
+	client, err := clients.forRepository(context.Background(), newRepository("owner/repo"))
+
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, "token token-7", authorization(t, client))
+	assert.Equal(t, int32(2), lookups.Load())
+}
+
+func TestGitHubClientsEvictStaleInstallation(t *testing.T) {
+	// arrange
+	server, lookups := newGitHubAppTestServerWithLookup(t, func(n int32) (int, int64) { return http.StatusOK, int64(6 + n) }, nil)
+	clients, err := newGitHubClients(newTestGitHubAppAuth(t, server.URL), RateLimitConfig{}, zap.NewNop())
+	require.NoError(t, err)
+	_, err = clients.forRepository(context.Background(), newRepository("owner/repo"))
+	require.NoError(t, err)
+	serverErr := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusInternalServerError}}
+	notFound := &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+
+	// act
+	keptOnServerError := !clients.evict("owner", serverErr)
+	evicted := clients.evict("Owner", notFound)
+	client, err := clients.forRepository(context.Background(), newRepository("owner/repo"))
+	require.NoError(t, err)
+
+	// assert
+	assert.True(t, keptOnServerError)
+	assert.True(t, evicted)
+	assert.False(t, clients.evict("owner", errors.New("unknown")))
+	assert.Equal(t, "token token-8", authorization(t, client))
+	assert.Equal(t, int32(2), lookups.Load())
+}
+
+func TestStaleInstallationIsLookedUpAgain(t *testing.T) {
+	// arrange
+	server, lookups := newGitHubAppTestServerWithLookup(t,
+		func(n int32) (int, int64) { return http.StatusOK, int64(6 + n) },
+		func(installationID string) bool { return installationID == "7" },
+	)
+	cfg := newTestConfig(t)
+	cfg.GitHubAuth = newTestGitHubAppAuth(t, server.URL)
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, server)
+	var err error
+	rec.ghClients, err = newGitHubClients(cfg.GitHubAuth, RateLimitConfig{}, zap.NewNop())
+	require.NoError(t, err)
+	// the installation 7 is cached, then removed
+	_, err = rec.ghClients.forRepository(context.Background(), newRepository("owner/repo"))
+	require.NoError(t, err)
+	rec.ctx = context.Background()
+	event := newWorkflowJobEvent(1)
+
+	// act
+	rec.processWorkItem(workItem{event: event, dedupKeys: []string{jobDedupKey(1, 1)}})
+
+	// assert
+	assert.Equal(t, 1, sink.LogRecordCount())
+	assert.Equal(t, int32(2), lookups.Load())
+}
+
+func TestInstallationLookupFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		persisted  int
+	}{
+		{"not installed is removed", http.StatusNotFound, 0},
+		{"retries exhausted is requeued", http.StatusServiceUnavailable, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			server, _ := newGitHubAppTestServerWithLookup(t, func(int32) (int, int64) { return tt.statusCode, 0 }, nil)
+			cfg := newTestConfig(t)
+			cfg.GitHubAuth = newTestGitHubAppAuth(t, server.URL)
+			cfg.Retry = RetryConfig{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, MaxElapsedTime: 10 * time.Millisecond, RequeueDelay: time.Hour, MaxRequeues: 1}
+			storageID := component.MustNewID("file_storage")
+			cfg.StorageID = &storageID
+			host := newStorageHost(storageID, newMemoryStorage())
+			sink := new(consumertest.LogsSink)
+			rec := newTestReceiver(t, cfg, sink, server)
+			var err error
+			rec.ghClients, err = newGitHubClients(cfg.GitHubAuth, RateLimitConfig{}, zap.NewNop())
+			require.NoError(t, err)
+			rec.ghClients.retry = rec.retryGitHubCall
+			core, logs := observer.New(zap.ErrorLevel)
+			rec.logger = zap.New(core)
+			require.NoError(t, rec.Start(context.Background(), host))
+
+			// act
+			require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+
+			// assert
+			require.Eventually(t, func() bool { return logs.FilterMessage("Failed to process webhook event").Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+			require.NoError(t, rec.Shutdown(context.Background()))
+			assert.Equal(t, 0, sink.LogRecordCount())
+			items, err := rec.store.load(context.Background())
+			require.NoError(t, err)
+			assert.Len(t, items, tt.persisted)
+		})
+	}
+}
+
+func TestGitHubClientsRetryStale(t *testing.T) {
+	// arrange
+	server, lookups := newGitHubAppTestServer(t)
+	clients, err := newGitHubClients(newTestGitHubAppAuth(t, server.URL), RateLimitConfig{}, zap.NewNop())
+	require.NoError(t, err)
+	var calls int
+	call := func() error {
+		calls++
+		if _, err := clients.forRepository(context.Background(), newRepository("owner/repo")); err != nil {
+			return err
+		}
+		if calls == 1 {
+			return &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusUnauthorized}}
+		}
+		return nil
+	}
+
+	// act
+	err = clients.retryStale("owner", call)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, int32(2), lookups.Load())
+}
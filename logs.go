@@ -8,28 +8,44 @@ import (
 	"go.opentelemetry.io/collector/pdata/plog"
 )
 
-func attachData(logRecord *plog.LogRecord, repository Repository, run Run, logLine LogLine) error {
-	logRecord.SetSeverityNumber(plog.SeverityNumber(logLine.SeverityNumber))
+func attachData(logRecord *plog.LogRecord, severity SeverityConfig, repository Repository, run Run, job Job, annotation Annotation, logLine LogLine) error {
+	logRecord.SetSeverityNumber(mapSeverity(severity, logLine.SeverityText))
 	logRecord.SetSeverityText(logLine.SeverityText)
 	if err := attachTraceId(logRecord, run); err != nil {
 		return err
 	}
+	if err := attachSpanId(logRecord, run, job); err != nil {
+		return err
+	}
 	logRecord.SetTimestamp(pcommon.NewTimestampFromTime(logLine.Timestamp))
 	logRecord.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Now()))
 	logRecord.Body().SetStr(logLine.Body)
-	attachRepositoryAttributes(logRecord, repository)
-	attachRunAttributes(logRecord, run)
+	attachRepositoryAttributes(logRecord.Attributes(), repository)
+	attachRunAttributes(logRecord.Attributes(), run)
+	attachJobAttributes(logRecord.Attributes(), job)
+	attachAnnotationAttributes(logRecord.Attributes(), annotation)
 	return nil
 }
 
+// attachCheckRunData fills the log record of an annotation created on a check run by a GitHub App.
+// Unlike workflow jobs, check runs are not part of a workflow run trace.
+func attachCheckRunData(logRecord *plog.LogRecord, severity SeverityConfig, repository Repository, checkRun CheckRun, annotation Annotation, logLine LogLine) {
+	logRecord.SetSeverityNumber(mapSeverity(severity, logLine.SeverityText))
+	logRecord.SetSeverityText(logLine.SeverityText)
+	logRecord.SetTimestamp(pcommon.NewTimestampFromTime(logLine.Timestamp))
+	logRecord.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	logRecord.Body().SetStr(logLine.Body)
+	attachRepositoryAttributes(logRecord.Attributes(), repository)
+	attachCheckRunAttributes(logRecord.Attributes(), checkRun)
+	attachAnnotationAttributes(logRecord.Attributes(), annotation)
+}
+
 // parseAnnotationToLogLine parses an annotation from the GitHub Actions log file
 func parseAnnotationToLogLine(completedAt time.Time, line *github.CheckRunAnnotation) LogLine {
-	var severityNumber = 0 // Unspecified
 	return LogLine{
-		Body:           line.GetMessage(),
-		Timestamp:      completedAt,
-		SeverityNumber: severityNumber,
-		SeverityText:   line.GetAnnotationLevel(),
+		Body:         line.GetMessage(),
+		Timestamp:    completedAt,
+		SeverityText: line.GetAnnotationLevel(),
 	}
 }
 
@@ -42,18 +58,84 @@ func attachTraceId(logRecord *plog.LogRecord, run Run) error {
 	return nil
 }
 
-func attachRepositoryAttributes(logRecord *plog.LogRecord, repository Repository) {
-	logRecord.Attributes().PutStr("github.repository", repository.FullName)
+// attachSpanId links the log record to the job span, see generateJobSpanID
+func attachSpanId(logRecord *plog.LogRecord, run Run, job Job) error {
+	spanId, err := generateJobSpanID(run.ID, int(run.RunAttempt), job.Name)
+	if err != nil {
+		return err
+	}
+	logRecord.SetSpanID(spanId)
+	return nil
 }
 
-func attachRunAttributes(logRecord *plog.LogRecord, run Run) {
-	logRecord.Attributes().PutInt("github.workflow_run.id", run.ID)
-	logRecord.Attributes().PutInt("github.workflow_run.run_attempt", run.RunAttempt)
-	logRecord.Attributes().PutStr("github.workflow_run.conclusion", run.Conclusion)
-	logRecord.Attributes().PutStr("github.workflow_run.status", run.Status)
-	logRecord.Attributes().PutStr("github.workflow_run.run_started_at", pcommon.NewTimestampFromTime(run.RunStartedAt).String())
-	logRecord.Attributes().PutStr("github.workflow_run.created_at", pcommon.NewTimestampFromTime(run.CreatedAt).String())
-	logRecord.Attributes().PutStr("github.workflow_run.completed_at", pcommon.NewTimestampFromTime(run.CompletedAt).String())
-	logRecord.Attributes().PutStr("github.workflow_run.head_branch", run.HeadBranch)
-	logRecord.Attributes().PutStr("github.workflow_run.html_url", run.URL)
+func attachRepositoryAttributes(attributes pcommon.Map, repository Repository) {
+	attributes.PutStr("github.repository", repository.FullName)
+}
+
+func attachRunAttributes(attributes pcommon.Map, run Run) {
+	attributes.PutInt("github.workflow_run.id", run.ID)
+	attributes.PutInt("github.workflow_run.run_attempt", run.RunAttempt)
+	attributes.PutStr("github.workflow_run.conclusion", run.Conclusion)
+	attributes.PutStr("github.workflow_run.status", run.Status)
+	attributes.PutStr("github.workflow_run.run_started_at", pcommon.NewTimestampFromTime(run.RunStartedAt).String())
+	attributes.PutStr("github.workflow_run.created_at", pcommon.NewTimestampFromTime(run.CreatedAt).String())
+	attributes.PutStr("github.workflow_run.completed_at", pcommon.NewTimestampFromTime(run.CompletedAt).String())
+	attributes.PutStr("github.workflow_run.head_branch", run.HeadBranch)
+	attributes.PutStr("github.workflow_run.html_url", run.URL)
+}
+
+func attachJobAttributes(attributes pcommon.Map, job Job) {
+	attributes.PutInt("github.workflow_job.id", job.ID)
+	attributes.PutStr("github.workflow_job.name", job.Name)
+	attributes.PutStr("github.workflow_job.workflow_name", job.WorkflowName)
+	attributes.PutStr("github.workflow_job.head_sha", job.HeadSHA)
+	attributes.PutStr("github.workflow_job.runner_name", job.RunnerName)
+	attributes.PutStr("github.workflow_job.runner_group_name", job.RunnerGroupName)
+	labels := attributes.PutEmptySlice("github.workflow_job.labels")
+	for _, label := range job.Labels {
+		labels.AppendEmpty().SetStr(label)
+	}
+	attributes.PutStr("github.workflow_job.html_url", job.URL)
+}
+
+func attachCheckRunAttributes(attributes pcommon.Map, checkRun CheckRun) {
+	attributes.PutInt("github.check_run.id", checkRun.ID)
+	attributes.PutStr("github.check_run.name", checkRun.Name)
+	attributes.PutStr("github.check_run.head_sha", checkRun.HeadSHA)
+	attributes.PutStr("github.check_run.status", checkRun.Status)
+	attributes.PutStr("github.check_run.conclusion", checkRun.Conclusion)
+	attributes.PutStr("github.check_run.started_at", pcommon.NewTimestampFromTime(checkRun.StartedAt).String())
+	attributes.PutStr("github.check_run.completed_at", pcommon.NewTimestampFromTime(checkRun.CompletedAt).String())
+	attributes.PutStr("github.check_run.html_url", checkRun.URL)
+	attributes.PutStr("github.app.slug", checkRun.AppSlug)
+	attributes.PutStr("github.app.name", checkRun.AppName)
+}
+
+// attachAnnotationAttributes attaches the annotation location using the code.* semantic conventions
+// where they fit, github.annotation.* otherwise. Fields GitHub did not set are omitted.
+func attachAnnotationAttributes(attributes pcommon.Map, annotation Annotation) {
+	if annotation.Path != "" {
+		attributes.PutStr("code.filepath", annotation.Path)
+	}
+	if annotation.StartLine > 0 {
+		attributes.PutInt("code.lineno", int64(annotation.StartLine))
+	}
+	if annotation.StartColumn > 0 {
+		attributes.PutInt("code.column", int64(annotation.StartColumn))
+	}
+	if annotation.EndLine > 0 {
+		attributes.PutInt("github.annotation.end_line", int64(annotation.EndLine))
+	}
+	if annotation.EndColumn > 0 {
+		attributes.PutInt("github.annotation.end_column", int64(annotation.EndColumn))
+	}
+	if annotation.Title != "" {
+		attributes.PutStr("github.annotation.title", annotation.Title)
+	}
+	if annotation.RawDetails != "" {
+		attributes.PutStr("github.annotation.raw_details", annotation.RawDetails)
+	}
+	if annotation.BlobHRef != "" {
+		attributes.PutStr("github.annotation.blob_href", annotation.BlobHRef)
+	}
 }
@@ -0,0 +1,132 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func TestMapSeverity(t *testing.T) {
+	defaultSeverity := createDefaultConfig().(*Config).Severity
+	tests := []struct {
+		name     string
+		severity SeverityConfig
+		level    string
+		expected plog.SeverityNumber
+	}{
+		{name: "notice", severity: defaultSeverity, level: "notice", expected: plog.SeverityNumberInfo},
+		{name: "warning", severity: defaultSeverity, level: "warning", expected: plog.SeverityNumberWarn},
+		{name: "failure", severity: defaultSeverity, level: "failure", expected: plog.SeverityNumberError},
+		{name: "mixed case level", severity: defaultSeverity, level: "Failure", expected: plog.SeverityNumberError},
+		{name: "unknown level", severity: defaultSeverity, level: "critical", expected: plog.SeverityNumberUnspecified},
+		{name: "empty level", severity: defaultSeverity, level: "", expected: plog.SeverityNumberUnspecified},
+		{name: "custom severity", severity: SeverityConfig{Notice: "debug", Warning: "ERROR", Failure: "FATAL"}, level: "notice", expected: plog.SeverityNumberDebug},
+		{name: "unset severity", severity: SeverityConfig{}, level: "warning", expected: plog.SeverityNumberUnspecified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, mapSeverity(tt.severity, tt.level))
+		})
+	}
+}
+
+func TestAttachDataSetsSeverity(t *testing.T) {
+	// arrange
+	logRecord := plog.NewLogRecord()
+	annotation := &github.CheckRunAnnotation{
+		Message:         github.String("Process completed with exit code 1."),
+		AnnotationLevel: github.String("failure"),
+	}
+	logLine := parseAnnotationToLogLine(time.Now(), annotation)
+
+	// act
+	err := attachData(&logRecord, createDefaultConfig().(*Config).Severity, Repository{}, Run{ID: 1, RunAttempt: 1}, Job{}, Annotation{}, logLine)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, plog.SeverityNumberError, logRecord.SeverityNumber())
+	assert.Equal(t, "failure", logRecord.SeverityText())
+	assert.Equal(t, "Process completed with exit code 1.", logRecord.Body().Str())
+}
+
+func TestAttachDataSetsAnnotationAttributes(t *testing.T) {
+	// arrange
+	logRecord := plog.NewLogRecord()
+	annotation := &checkRunAnnotation{
+		CheckRunAnnotation: github.CheckRunAnnotation{
+			Path:            github.String("main.go"),
+			StartLine:       github.Int(10),
+			EndLine:         github.Int(12),
+			StartColumn:     github.Int(3),
+			AnnotationLevel: github.String("warning"),
+			Message:         github.String("unused variable"),
+			Title:           github.String("golangci-lint"),
+		},
+		BlobHRef: github.String("https://github.com/owner/repo/blob/abc/main.go"),
+	}
+	logLine := parseAnnotationToLogLine(time.Now(), &annotation.CheckRunAnnotation)
+
+	// act
+	err := attachData(&logRecord, SeverityConfig{}, Repository{}, Run{ID: 1, RunAttempt: 1}, Job{}, mapAnnotation(annotation), logLine)
+
+	// assert
+	assert.NoError(t, err)
+	attrs := logRecord.Attributes().AsRaw()
+	assert.Equal(t, "main.go", attrs["code.filepath"])
+	assert.Equal(t, int64(10), attrs["code.lineno"])
+	assert.Equal(t, int64(3), attrs["code.column"])
+	assert.Equal(t, int64(12), attrs["github.annotation.end_line"])
+	assert.Equal(t, "golangci-lint", attrs["github.annotation.title"])
+	assert.Equal(t, "https://github.com/owner/repo/blob/abc/main.go", attrs["github.annotation.blob_href"])
+	assert.NotContains(t, attrs, "github.annotation.end_column")
+	assert.NotContains(t, attrs, "github.annotation.raw_details")
+}
+
+func TestAttachDataSetsJobAttributes(t *testing.T) {
+	// arrange
+	logRecord := plog.NewLogRecord()
+	workflowJob := &github.WorkflowJob{
+		ID:              github.Int64(123),
+		Name:            github.String("build"),
+		WorkflowName:    github.String("CI"),
+		HeadSHA:         github.String("abc123"),
+		RunnerName:      github.String("runner-1"),
+		RunnerGroupName: github.String("default"),
+		Labels:          []string{"ubuntu-latest", "self-hosted"},
+		HTMLURL:         github.String("https://github.com/owner/repo/actions/runs/1/job/123"),
+	}
+
+	// act
+	err := attachData(&logRecord, SeverityConfig{}, Repository{}, Run{ID: 1, RunAttempt: 1}, mapJob(workflowJob), Annotation{}, LogLine{})
+
+	// assert
+	assert.NoError(t, err)
+	attrs := logRecord.Attributes().AsRaw()
+	assert.Equal(t, int64(123), attrs["github.workflow_job.id"])
+	assert.Equal(t, "build", attrs["github.workflow_job.name"])
+	assert.Equal(t, "CI", attrs["github.workflow_job.workflow_name"])
+	assert.Equal(t, "abc123", attrs["github.workflow_job.head_sha"])
+	assert.Equal(t, "runner-1", attrs["github.workflow_job.runner_name"])
+	assert.Equal(t, "default", attrs["github.workflow_job.runner_group_name"])
+	assert.Equal(t, []any{"ubuntu-latest", "self-hosted"}, attrs["github.workflow_job.labels"])
+	assert.Equal(t, "https://github.com/owner/repo/actions/runs/1/job/123", attrs["github.workflow_job.html_url"])
+}
+
+func TestAttachDataSetsTraceAndSpanIds(t *testing.T) {
+	// arrange
+	logRecord := plog.NewLogRecord()
+	run := Run{ID: 10, RunAttempt: 2}
+	job := Job{Name: "build"}
+
+	// act
+	err := attachData(&logRecord, SeverityConfig{}, Repository{}, run, job, Annotation{}, LogLine{})
+
+	// assert
+	assert.NoError(t, err)
+	// computed with the githubactionsreceiver trace_event_handling.go for the run 10, attempt 2 and job build
+	assert.Equal(t, "54dcf2d2dabf72da7447767856d4e70b", logRecord.TraceID().String())
+	assert.Equal(t, "3e798a9519a283fe", logRecord.SpanID().String())
+}
@@ -0,0 +1,93 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// attachAnnotationMetrics adds to the scope metrics the annotation counters of a job:
+// the annotations by level, the annotations by path and level, and whether the job
+// had failure annotations. Counters are deltas covering the job execution. The counters by
+// level and by path are only added if they have data points.
+func attachAnnotationMetrics(scopeMetrics pmetric.ScopeMetrics, batch []*checkRunAnnotation, repository Repository, run Run, job Job) {
+	byLevel := map[string]int64{}
+	byPath := map[[2]string]int64{}
+	var hasFailures int64
+	for _, annotation := range batch {
+		level := strings.ToLower(annotation.GetAnnotationLevel())
+		byLevel[level]++
+		if annotation.GetPath() != "" {
+			byPath[[2]string{annotation.GetPath(), level}]++
+		}
+		if level == annotationLevelFailure {
+			hasFailures = 1
+		}
+	}
+	startTimestamp := pcommon.NewTimestampFromTime(run.RunStartedAt)
+	timestamp := pcommon.NewTimestampFromTime(run.CompletedAt)
+
+	if len(byLevel) > 0 {
+		annotations := newDeltaSum(scopeMetrics, "github.annotations", "Number of annotations by level", "{annotation}")
+		for _, level := range sortedKeys(byLevel) {
+			dataPoint := annotations.DataPoints().AppendEmpty()
+			setDataPoint(dataPoint, startTimestamp, timestamp, byLevel[level], repository, run, job)
+			dataPoint.Attributes().PutStr("github.annotation.level", level)
+		}
+	}
+
+	paths := make([][2]string, 0, len(byPath))
+	for pathAndLevel := range byPath {
+		paths = append(paths, pathAndLevel)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i][0] != paths[j][0] {
+			return paths[i][0] < paths[j][0]
+		}
+		return paths[i][1] < paths[j][1]
+	})
+	if len(paths) > 0 {
+		annotationsByPath := newDeltaSum(scopeMetrics, "github.annotations.by_path", "Number of annotations by path and level", "{annotation}")
+		for _, pathAndLevel := range paths {
+			dataPoint := annotationsByPath.DataPoints().AppendEmpty()
+			setDataPoint(dataPoint, startTimestamp, timestamp, byPath[pathAndLevel], repository, run, job)
+			dataPoint.Attributes().PutStr("code.filepath", pathAndLevel[0])
+			dataPoint.Attributes().PutStr("github.annotation.level", pathAndLevel[1])
+		}
+	}
+
+	jobsWithFailures := newDeltaSum(scopeMetrics, "github.jobs.with_failure_annotations", "Number of jobs with at least one failure annotation", "{job}")
+	setDataPoint(jobsWithFailures.DataPoints().AppendEmpty(), startTimestamp, timestamp, hasFailures, repository, run, job)
+}
+
+func newDeltaSum(scopeMetrics pmetric.ScopeMetrics, name string, description string, unit string) pmetric.Sum {
+	metric := scopeMetrics.Metrics().AppendEmpty()
+	metric.SetName(name)
+	metric.SetDescription(description)
+	metric.SetUnit(unit)
+	sum := metric.SetEmptySum()
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	sum.SetIsMonotonic(true)
+	return sum
+}
+
+func setDataPoint(dataPoint pmetric.NumberDataPoint, startTimestamp pcommon.Timestamp, timestamp pcommon.Timestamp, value int64, repository Repository, run Run, job Job) {
+	dataPoint.SetStartTimestamp(startTimestamp)
+	dataPoint.SetTimestamp(timestamp)
+	dataPoint.SetIntValue(value)
+	dataPoint.Attributes().PutStr("github.repository", repository.FullName)
+	dataPoint.Attributes().PutStr("github.workflow_run.head_branch", run.HeadBranch)
+	dataPoint.Attributes().PutStr("github.workflow_job.workflow_name", job.WorkflowName)
+	dataPoint.Attributes().PutStr("github.workflow_job.name", job.Name)
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func newTestAnnotation(path string, level string) *checkRunAnnotation {
+	return &checkRunAnnotation{
+		CheckRunAnnotation: github.CheckRunAnnotation{
+			Path:            github.String(path),
+			AnnotationLevel: github.String(level),
+			Message:         github.String("message"),
+		},
+	}
+}
+
+func TestAttachAnnotationMetrics(t *testing.T) {
+	// arrange
+	scopeMetrics := pmetric.NewScopeMetrics()
+	batch := []*checkRunAnnotation{
+		newTestAnnotation("main.go", "warning"),
+		newTestAnnotation("main.go", "warning"),
+		newTestAnnotation("main.go", "failure"),
+		newTestAnnotation("", "notice"),
+	}
+	completedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	run := Run{HeadBranch: "main", RunStartedAt: completedAt.Add(-time.Minute), CompletedAt: completedAt}
+	job := Job{Name: "build", WorkflowName: "CI"}
+
+	// act
+	attachAnnotationMetrics(scopeMetrics, batch, Repository{FullName: "owner/repo"}, run, job)
+
+	// assert
+	require.Equal(t, 3, scopeMetrics.Metrics().Len())
+
+	annotations := scopeMetrics.Metrics().At(0)
+	assert.Equal(t, "github.annotations", annotations.Name())
+	assert.Equal(t, pmetric.AggregationTemporalityDelta, annotations.Sum().AggregationTemporality())
+	byLevel := map[any]int64{}
+	for i := 0; i < annotations.Sum().DataPoints().Len(); i++ {
+		dataPoint := annotations.Sum().DataPoints().At(i)
+		attrs := dataPoint.Attributes().AsRaw()
+		assert.Equal(t, "owner/repo", attrs["github.repository"])
+		assert.Equal(t, "main", attrs["github.workflow_run.head_branch"])
+		assert.Equal(t, "CI", attrs["github.workflow_job.workflow_name"])
+		assert.Equal(t, "build", attrs["github.workflow_job.name"])
+		assert.Equal(t, completedAt, dataPoint.Timestamp().AsTime())
+		byLevel[attrs["github.annotation.level"]] = dataPoint.IntValue()
+	}
+	assert.Equal(t, map[any]int64{"failure": 1, "notice": 1, "warning": 2}, byLevel)
+
+	byPath := scopeMetrics.Metrics().At(1)
+	assert.Equal(t, "github.annotations.by_path", byPath.Name())
+	require.Equal(t, 2, byPath.Sum().DataPoints().Len())
+	assert.Equal(t, "main.go", byPath.Sum().DataPoints().At(0).Attributes().AsRaw()["code.filepath"])
+	assert.Equal(t, "failure", byPath.Sum().DataPoints().At(0).Attributes().AsRaw()["github.annotation.level"])
+	assert.Equal(t, int64(1), byPath.Sum().DataPoints().At(0).IntValue())
+	assert.Equal(t, int64(2), byPath.Sum().DataPoints().At(1).IntValue())
+
+	jobsWithFailures := scopeMetrics.Metrics().At(2)
+	assert.Equal(t, "github.jobs.with_failure_annotations", jobsWithFailures.Name())
+	assert.Equal(t, int64(1), jobsWithFailures.Sum().DataPoints().At(0).IntValue())
+}
+
+func TestAttachAnnotationMetricsWithoutAnnotations(t *testing.T) {
+	scopeMetrics := pmetric.NewScopeMetrics()
+
+	attachAnnotationMetrics(scopeMetrics, nil, Repository{}, Run{}, Job{})
+
+	require.Equal(t, 1, scopeMetrics.Metrics().Len())
+	assert.Equal(t, "github.jobs.with_failure_annotations", scopeMetrics.Metrics().At(0).Name())
+	assert.Equal(t, int64(0), scopeMetrics.Metrics().At(0).Sum().DataPoints().At(0).IntValue())
+}
+
+func TestAttachAnnotationMetricsWithoutPaths(t *testing.T) {
+	scopeMetrics := pmetric.NewScopeMetrics()
+
+	attachAnnotationMetrics(scopeMetrics, []*checkRunAnnotation{newTestAnnotation("", "warning")}, Repository{}, Run{}, Job{})
+
+	require.Equal(t, 2, scopeMetrics.Metrics().Len())
+	assert.Equal(t, "github.annotations", scopeMetrics.Metrics().At(0).Name())
+	assert.Equal(t, "github.jobs.with_failure_annotations", scopeMetrics.Metrics().At(1).Name())
+}
@@ -7,10 +7,9 @@ import (
 )
 
 type LogLine struct {
-	Body           string
-	Timestamp      time.Time
-	SeverityNumber int
-	SeverityText   string
+	Body         string
+	Timestamp    time.Time
+	SeverityText string
 }
 
 type Repository struct {
@@ -31,6 +30,41 @@ type Run struct {
 	HeadBranch   string
 }
 
+type Job struct {
+	ID              int64
+	Name            string
+	WorkflowName    string
+	HeadSHA         string
+	RunnerName      string
+	RunnerGroupName string
+	Labels          []string
+	URL             string
+}
+
+type CheckRun struct {
+	ID          int64
+	Name        string
+	HeadSHA     string
+	Status      string
+	Conclusion  string
+	StartedAt   time.Time
+	CompletedAt time.Time
+	URL         string
+	AppSlug     string
+	AppName     string
+}
+
+type Annotation struct {
+	Path        string
+	StartLine   int
+	EndLine     int
+	StartColumn int
+	EndColumn   int
+	Title       string
+	RawDetails  string
+	BlobHRef    string
+}
+
 func mapRun(run *github.WorkflowJob) Run {
 	return Run{
 		ID:           *run.RunID,
@@ -45,6 +79,34 @@ func mapRun(run *github.WorkflowJob) Run {
 	}
 }
 
+func mapJob(job *github.WorkflowJob) Job {
+	return Job{
+		ID:              job.GetID(),
+		Name:            job.GetName(),
+		WorkflowName:    job.GetWorkflowName(),
+		HeadSHA:         job.GetHeadSHA(),
+		RunnerName:      job.GetRunnerName(),
+		RunnerGroupName: job.GetRunnerGroupName(),
+		Labels:          job.Labels,
+		URL:             job.GetHTMLURL(),
+	}
+}
+
+func mapCheckRun(checkRun *github.CheckRun) CheckRun {
+	return CheckRun{
+		ID:          checkRun.GetID(),
+		Name:        checkRun.GetName(),
+		HeadSHA:     checkRun.GetHeadSHA(),
+		Status:      checkRun.GetStatus(),
+		Conclusion:  checkRun.GetConclusion(),
+		StartedAt:   checkRun.GetStartedAt().Time,
+		CompletedAt: checkRun.GetCompletedAt().Time,
+		URL:         checkRun.GetHTMLURL(),
+		AppSlug:     checkRun.GetApp().GetSlug(),
+		AppName:     checkRun.GetApp().GetName(),
+	}
+}
+
 func mapRepository(repo *github.Repository) Repository {
 	return Repository{
 		FullName: repo.GetFullName(),
@@ -52,3 +114,16 @@ func mapRepository(repo *github.Repository) Repository {
 		Name:     repo.GetName(),
 	}
 }
+
+func mapAnnotation(annotation *checkRunAnnotation) Annotation {
+	return Annotation{
+		Path:        annotation.GetPath(),
+		StartLine:   annotation.GetStartLine(),
+		EndLine:     annotation.GetEndLine(),
+		StartColumn: annotation.GetStartColumn(),
+		EndColumn:   annotation.GetEndColumn(),
+		Title:       annotation.GetTitle(),
+		RawDetails:  annotation.GetRawDetails(),
+		BlobHRef:    annotation.GetBlobHRef(),
+	}
+}
@@ -0,0 +1,132 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/zap"
+)
+
+// runPolling polls the configured repositories every polling.interval until ctx is cancelled
+func (rec *githubactionsannotationsreceiver) runPolling(ctx context.Context) {
+	ctx = withRateLimitReserve(ctx, rec.config.Polling.MinRateLimitRemaining)
+	ticker := time.NewTicker(rec.config.Polling.Interval)
+	defer ticker.Stop()
+	for {
+		rec.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll processes the workflow runs completed since the previous poll in each configured repository
+func (rec *githubactionsannotationsreceiver) poll(ctx context.Context) {
+	now := time.Now()
+	repos, err := rec.listPolledRepositories(ctx)
+	if err != nil {
+		rec.logger.Error("Failed to list the repositories to poll", zap.Error(err))
+	}
+	for _, repo := range repos {
+		err := rec.ghClients.retryStale(repo.GetOwner().GetLogin(), func() error {
+			return rec.pollRepository(ctx, repo, now)
+		})
+		if err != nil {
+			rec.logger.Error("Polling failed", zap.String("github.repository", repo.GetFullName()), zap.Error(err))
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// pollRepository lists the completed runs created since the checkpoint minus polling.lookback,
+// processes the ones updated after the checkpoint and moves the checkpoint to now
+func (rec *githubactionsannotationsreceiver) pollRepository(ctx context.Context, repo *github.Repository, now time.Time) error {
+	ghClient, err := rec.ghClients.forRepository(ctx, repo)
+	if err != nil {
+		return err
+	}
+	checkpointName := fmt.Sprintf("polling_%s", repo.GetFullName())
+	checkpoint, err := rec.store.loadCheckpoint(ctx, checkpointName)
+	if err != nil {
+		return err
+	}
+	if checkpoint.IsZero() {
+		checkpoint = now.Add(-rec.config.Polling.Lookback)
+	}
+	runs, err := rec.listCompletedWorkflowRuns(ctx, ghClient, repo, checkpoint.Add(-rec.config.Polling.Lookback), now)
+	if err != nil {
+		return err
+	}
+	var collected int
+	for _, run := range runs {
+		if !run.GetUpdatedAt().After(checkpoint) {
+			continue
+		}
+		// the checkpoint must not move past a failed run, the next poll lists it again
+		if err := rec.collectWorkflowRun(ctx, ghClient, repo, run); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		collected++
+	}
+	if err := rec.store.saveCheckpoint(ctx, checkpointName, now); err != nil {
+		return fmt.Errorf("failed to save polling checkpoint: %w", err)
+	}
+	rec.logger.Debug("Polled workflow runs", zap.String("github.repository", repo.GetFullName()), zap.Int("count", collected))
+	return nil
+}
+
+// listPolledRepositories returns the configured repositories followed by the repositories
+// of the configured organizations, except the archived ones and the ones excluded by the filters
+func (rec *githubactionsannotationsreceiver) listPolledRepositories(ctx context.Context) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	for _, fullName := range rec.config.Polling.Repositories {
+		repos = append(repos, newRepository(fullName))
+	}
+	for _, org := range rec.config.Polling.Organizations {
+		withOrgInfoFields := func(fields ...zap.Field) []zap.Field {
+			return append([]zap.Field{zap.String("github.organization", org)}, fields...)
+		}
+		ghClient, err := rec.ghClients.forOrganization(ctx, org)
+		if err != nil {
+			return repos, err
+		}
+		listOpts := &github.RepositoryListByOrgOptions{
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+			},
+		}
+		for {
+			var orgRepos []*github.Repository
+			var response *github.Response
+			err := rec.retryGitHubCall(ctx, withOrgInfoFields, func(ctx context.Context) error {
+				var err error
+				orgRepos, response, err = ghClient.Repositories.ListByOrg(ctx, org, listOpts)
+				return err
+			})
+			if err != nil {
+				// a stale installation is looked up again on the next poll
+				rec.ghClients.evict(org, err)
+				return repos, fmt.Errorf("failed to list the repositories of %q: %w", org, err)
+			}
+			for _, repo := range orgRepos {
+				if !repo.GetArchived() && rec.filter.allowsRepository(repo) {
+					repos = append(repos, repo)
+				}
+			}
+			if response.NextPage == 0 {
+				break
+			}
+			listOpts.Page = response.NextPage
+		}
+	}
+	return repos, nil
+}
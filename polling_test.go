@@ -0,0 +1,128 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+)
+
+// newPollingTestServer starts a fake GitHub API with the organization acme owning owner/repo,
+// whose completed runs are the given ones. Every run has two jobs with a single annotation each.
+// It records the created filter of each workflow runs list request.
+func newPollingTestServer(t *testing.T, runs []*github.WorkflowRun) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var createdFilters []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/rate_limit":
+			fmt.Fprint(w, `{"resources":{"core":{"limit":5000,"remaining":4999,"reset":1700000000}}}`)
+		case r.URL.Path == "/orgs/acme/repos":
+			require.NoError(t, json.NewEncoder(w).Encode([]*github.Repository{
+				newRepository("owner/repo"),
+				{FullName: github.String("owner/archived"), Name: github.String("archived"), Archived: github.Bool(true)},
+			}))
+		case r.URL.Path == "/repos/owner/repo/actions/runs":
+			mu.Lock()
+			createdFilters = append(createdFilters, r.URL.Query().Get("created"))
+			mu.Unlock()
+			require.NoError(t, json.NewEncoder(w).Encode(&github.WorkflowRuns{TotalCount: github.Int(len(runs)), WorkflowRuns: runs}))
+		case strings.HasSuffix(r.URL.Path, "/jobs"):
+			jobs := []*github.WorkflowJob{newWorkflowJobEvent(1).GetWorkflowJob(), newWorkflowJobEvent(2).GetWorkflowJob()}
+			require.NoError(t, json.NewEncoder(w).Encode(&github.Jobs{TotalCount: github.Int(len(jobs)), Jobs: jobs}))
+		case strings.HasPrefix(r.URL.Path, "/repos/owner/repo/check-runs/"):
+			fmt.Fprint(w, `[{"path":"main.go","start_line":1,"annotation_level":"failure","message":"boom"}]`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), createdFilters...)
+	}
+}
+
+func TestPollingCollectsRunsUpdatedSinceCheckpoint(t *testing.T) {
+	// arrange
+	checkpoint := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	seenRun := newWorkflowRunEvent(1).GetWorkflowRun()
+	seenRun.UpdatedAt = &github.Timestamp{Time: checkpoint.Add(-time.Minute)}
+	newRun := newWorkflowRunEvent(2).GetWorkflowRun()
+	newRun.UpdatedAt = &github.Timestamp{Time: checkpoint.Add(time.Minute)}
+	cfg := newTestConfig(t)
+	cfg.Polling = PollingConfig{
+		Organizations: []string{"acme"},
+		Interval:      time.Hour,
+		Lookback:      time.Hour,
+	}
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	host := newStorageHost(storageID, newMemoryStorage())
+	ghServer, createdFilters := newPollingTestServer(t, []*github.WorkflowRun{seenRun, newRun})
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, ghServer)
+	store, err := newPendingStore(context.Background(), host, &storageID, rec.settings.ID)
+	require.NoError(t, err)
+	require.NoError(t, store.saveCheckpoint(context.Background(), "polling_owner/repo", checkpoint))
+	polled := func(rec *githubactionsannotationsreceiver, after time.Time) func() bool {
+		return func() bool {
+			saved, err := rec.store.loadCheckpoint(context.Background(), "polling_owner/repo")
+			return err == nil && saved.After(after)
+		}
+	}
+
+	// act
+	require.NoError(t, rec.Start(context.Background(), host))
+	require.Eventually(t, polled(rec, checkpoint), 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	firstPoll, err := store.loadCheckpoint(context.Background(), "polling_owner/repo")
+	require.NoError(t, err)
+	restarted := newTestReceiver(t, cfg, sink, ghServer)
+	require.NoError(t, restarted.Start(context.Background(), host))
+	require.Eventually(t, polled(restarted, firstPoll), 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, restarted.Shutdown(context.Background()))
+
+	// assert
+	require.Len(t, createdFilters(), 2)
+	assert.True(t, strings.HasPrefix(createdFilters()[0], checkpoint.Add(-time.Hour).UTC().Format(time.RFC3339)+".."))
+	assert.Equal(t, 2, sink.LogRecordCount())
+}
+
+func TestPollingCheckpointDoesNotMovePastFailedRun(t *testing.T) {
+	// arrange
+	checkpoint := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	run := newWorkflowRunEvent(1).GetWorkflowRun()
+	run.UpdatedAt = &github.Timestamp{Time: checkpoint.Add(time.Minute)}
+	cfg := newTestConfig(t)
+	cfg.Polling = PollingConfig{
+		Repositories: []string{"owner/repo"},
+		Interval:     time.Hour,
+		Lookback:     time.Hour,
+	}
+	ghServer, _ := newPollingTestServer(t, []*github.WorkflowRun{run})
+	rec := newStoreTestReceiver(t, cfg, withFailingCheckRun(t, ghServer, 2))
+	require.NoError(t, rec.store.saveCheckpoint(context.Background(), "polling_owner/repo", checkpoint))
+
+	// act
+	err := rec.pollRepository(context.Background(), newRepository("owner/repo"), time.Now())
+
+	// assert
+	assert.ErrorContains(t, err, "failed to collect workflow run 1")
+	saved, err := rec.store.loadCheckpoint(context.Background(), "polling_owner/repo")
+	require.NoError(t, err)
+	assert.True(t, saved.Equal(checkpoint))
+}
@@ -0,0 +1,121 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/zap"
+)
+
+const (
+	// overflowPolicyReject rejects new webhooks while the queue is full
+	overflowPolicyReject = "reject"
+	// overflowPolicyDropOldest drops the oldest queued webhook to make room for the new one
+	overflowPolicyDropOldest = "drop_oldest"
+)
+
+var (
+	errQueueFull   = errors.New("queue is full")
+	errQueueClosed = errors.New("queue is closed")
+)
+
+// workItem is a webhook accepted by the HTTP handler and waiting to be processed.
+// It holds either a workflow job event, a workflow run event or a check run event.
+type workItem struct {
+	event            *github.WorkflowJobEvent
+	workflowRunEvent *github.WorkflowRunEvent
+	checkRunEvent    *github.CheckRunEvent
+	// dedupKeys are forgotten if the processing fails so that a redelivery is processed again
+	dedupKeys []string
+	// requeues is the number of times the item was queued again after its processing failed
+	requeues int
+}
+
+// infoFields returns a function prepending the identity of the item to log fields
+func (item workItem) infoFields() func(fields ...zap.Field) []zap.Field {
+	if item.checkRunEvent != nil {
+		return newCheckRunInfoFields(item.checkRunEvent)
+	}
+	if item.workflowRunEvent != nil {
+		return newWorkflowRunInfoFields(item.workflowRunEvent)
+	}
+	return newWorkflowInfoFields(item.event)
+}
+
+// source returns the GitHub App installation that sent the item, if any, and its repository
+func (item workItem) source() (*github.Installation, *github.Repository) {
+	if item.checkRunEvent != nil {
+		return item.checkRunEvent.GetInstallation(), item.checkRunEvent.GetRepo()
+	}
+	if item.workflowRunEvent != nil {
+		return item.workflowRunEvent.GetInstallation(), item.workflowRunEvent.GetRepo()
+	}
+	return item.event.GetInstallation(), item.event.GetRepo()
+}
+
+// workQueue is a bounded in-memory queue of webhooks waiting to be processed by the workers
+type workQueue struct {
+	mu     sync.Mutex
+	items  chan workItem
+	closed bool
+	policy string
+}
+
+func newWorkQueue(size int, policy string) *workQueue {
+	return &workQueue{
+		items:  make(chan workItem, size),
+		policy: policy,
+	}
+}
+
+// push adds an item to the queue without blocking. When the queue is full the overflow
+// policy decides whether the item is rejected or the oldest item is dropped, in which case
+// the dropped item is returned.
+func (q *workQueue) push(item workItem) (*workItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, errQueueClosed
+	}
+	select {
+	case q.items <- item:
+		return nil, nil
+	default:
+	}
+	if q.policy != overflowPolicyDropOldest {
+		return nil, errQueueFull
+	}
+	var dropped *workItem
+	select {
+	case oldest := <-q.items:
+		dropped = &oldest
+	default:
+	}
+	// pushes are serialized by the mutex and workers only consume, so there is room now, unless
+	// the queue has no capacity and nothing was dropped: never block while holding the mutex
+	select {
+	case q.items <- item:
+		return dropped, nil
+	default:
+		return nil, errQueueFull
+	}
+}
+
+// close stops accepting new items. Items already queued can still be consumed.
+func (q *workQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.items)
+	}
+}
+
+func (q *workQueue) size() int {
+	return len(q.items)
+}
+
+func (q *workQueue) capacity() int {
+	return cap(q.items)
+}
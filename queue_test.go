@@ -0,0 +1,62 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkQueuePushRejectWhenFull(t *testing.T) {
+	// arrange
+	queue := newWorkQueue(1, overflowPolicyReject)
+	_, err := queue.push(workItem{event: newWorkflowJobEvent(1)})
+	assert.NoError(t, err)
+
+	// act
+	dropped, err := queue.push(workItem{event: newWorkflowJobEvent(2)})
+
+	// assert
+	assert.ErrorIs(t, err, errQueueFull)
+	assert.Nil(t, dropped)
+	assert.Equal(t, 1, queue.size())
+	assert.Equal(t, int64(1), (<-queue.items).event.GetWorkflowJob().GetID())
+}
+
+func TestWorkQueuePushDropOldestWhenFull(t *testing.T) {
+	// arrange
+	queue := newWorkQueue(2, overflowPolicyDropOldest)
+	_, _ = queue.push(workItem{event: newWorkflowJobEvent(1)})
+	_, _ = queue.push(workItem{event: newWorkflowJobEvent(2)})
+
+	// act
+	dropped, err := queue.push(workItem{event: newWorkflowJobEvent(3)})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), dropped.event.GetWorkflowJob().GetID())
+	assert.Equal(t, 2, queue.size())
+	assert.Equal(t, int64(2), (<-queue.items).event.GetWorkflowJob().GetID())
+	assert.Equal(t, int64(3), (<-queue.items).event.GetWorkflowJob().GetID())
+}
+
+func TestWorkQueuePushAfterClose(t *testing.T) {
+	// arrange
+	queue := newWorkQueue(1, overflowPolicyReject)
+	queue.close()
+
+	// act
+	_, err := queue.push(workItem{event: newWorkflowJobEvent(1)})
+
+	// assert
+	assert.ErrorIs(t, err, errQueueClosed)
+}
+
+func TestWorkQueuePushDropOldestWithoutCapacity(t *testing.T) {
+	queue := newWorkQueue(0, overflowPolicyDropOldest)
+
+	dropped, err := queue.push(workItem{event: newWorkflowJobEvent(1)})
+
+	assert.ErrorIs(t, err, errQueueFull)
+	assert.Nil(t, dropped)
+	queue.close()
+}
@@ -0,0 +1,193 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// rateLimitMaxRetries is the number of times a request answered with a rate limit error is retried
+	rateLimitMaxRetries = 3
+	// secondaryRateLimitPause is the pause after a secondary rate limit without a Retry-After header,
+	// as recommended by the GitHub documentation
+	secondaryRateLimitPause = time.Minute
+)
+
+// rateLimitReserveKey is the context key of the rate limit reserve of a request
+type rateLimitReserveKey struct{}
+
+// withRateLimitReserve raises the rate limit reserve of the requests made with ctx to reserve, so that
+// the background collections leave more of the rate limit to the webhooks processing
+func withRateLimitReserve(ctx context.Context, reserve int) context.Context {
+	return context.WithValue(ctx, rateLimitReserveKey{}, reserve)
+}
+
+// rateLimitTransport pauses the GitHub API requests instead of letting them fail once the
+// primary rate limit budget is spent or after a secondary rate limit error, and retries
+// the requests answered with a rate limit error. A nil rateLimitTransport does nothing.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	reserve int
+	logger  *zap.Logger
+
+	mu sync.Mutex
+	// limit, remaining and reset are the primary rate limit of the last response, remaining is -1 until then
+	limit     int
+	remaining int
+	reset     time.Time
+	// pausedUntil is set by the rate limit errors
+	pausedUntil time.Time
+}
+
+func newRateLimitTransport(cfg RateLimitConfig, logger *zap.Logger) *rateLimitTransport {
+	return &rateLimitTransport{
+		reserve:   cfg.Reserve,
+		logger:    logger,
+		remaining: -1,
+	}
+}
+
+// wrap returns the transport sending the requests through base
+func (t *rateLimitTransport) wrap(base http.RoundTripper) http.RoundTripper {
+	if t == nil {
+		return base
+	}
+	t.base = base
+	return t
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		// getting the rate limit does not count against it
+		if !strings.HasSuffix(req.URL.Path, "/rate_limit") {
+			if err := t.wait(req.Context()); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		pause, limited := t.update(resp)
+		if !limited || attempt == rateLimitMaxRetries || req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, nil
+		}
+		t.logger.Warn("GitHub API rate limit exceeded, pausing before retrying the request",
+			zap.String("url", req.URL.Path),
+			zap.Int("status_code", resp.StatusCode),
+			zap.Duration("pause", pause),
+		)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// wait blocks until the pause set by a rate limit error ends and, if the remaining requests
+// are within the reserved budget, until the primary rate limit resets. The reserve is the greater
+// of rate_limit.reserve and the one set on ctx by withRateLimitReserve.
+func (t *rateLimitTransport) wait(ctx context.Context) error {
+	reserve := t.reserve
+	if ctxReserve, ok := ctx.Value(rateLimitReserveKey{}).(int); ok && ctxReserve > reserve {
+		reserve = ctxReserve
+	}
+	t.mu.Lock()
+	until := t.pausedUntil
+	remaining := t.remaining
+	if remaining >= 0 && remaining <= reserve && t.reset.After(until) {
+		until = t.reset
+	}
+	t.mu.Unlock()
+	delay := time.Until(until)
+	if delay <= 0 {
+		return nil
+	}
+	t.logger.Debug("Waiting for the GitHub API rate limit", zap.Int("remaining", remaining), zap.Time("until", until))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// update records the primary rate limit of the response and returns whether the response is
+// a rate limit error, with the pause it requires: the Retry-After header, the primary rate limit
+// reset if no request remains, and otherwise one minute for the secondary rate limits.
+func (t *rateLimitTransport) update(resp *http.Response) (time.Duration, bool) {
+	secondary := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden && isSecondaryRateLimit(resp)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit")); err == nil {
+		t.limit = limit
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		t.remaining = remaining
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		t.reset = time.Unix(reset, 0)
+	}
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	now := time.Now()
+	var until time.Time
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		until = now.Add(time.Duration(retryAfter) * time.Second)
+	} else if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		until = t.reset
+	} else if secondary {
+		until = now.Add(secondaryRateLimitPause)
+	} else {
+		// a permission error
+		return 0, false
+	}
+	if until.After(t.pausedUntil) {
+		t.pausedUntil = until
+	}
+	return max(until.Sub(now), 0), true
+}
+
+// state returns the primary rate limit of the last response, ok is false until then
+func (t *rateLimitTransport) state() (limit int, remaining int, ok bool) {
+	if t == nil {
+		return 0, 0, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.limit, t.remaining, t.remaining >= 0
+}
+
+// isSecondaryRateLimit returns whether the 403 response is a secondary rate limit error rather than
+// a permission error. GitHub only tells them apart in the message, so the body is read and restored.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	if resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		// handled from the headers
+		return false
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	message := strings.ToLower(string(body))
+	return strings.Contains(message, "secondary rate limit") || strings.Contains(message, "secondary-rate-limits")
+}
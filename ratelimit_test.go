@@ -0,0 +1,160 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newRateLimitTestClient returns a client sending its requests to handler through a rate limit transport
+func newRateLimitTestClient(t *testing.T, reserve int, handler http.HandlerFunc) (*http.Client, *rateLimitTransport, string) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	rateLimiter := newRateLimitTransport(RateLimitConfig{Reserve: reserve}, zap.NewNop())
+	return &http.Client{Transport: rateLimiter.wrap(http.DefaultTransport)}, rateLimiter, server.URL
+}
+
+func TestRateLimitTransportRetriesAfterRetryAfter(t *testing.T) {
+	// arrange
+	var calls atomic.Int32
+	client, _, url := newRateLimitTestClient(t, 0, func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	start := time.Now()
+
+	// act
+	resp, err := client.Get(url + "/repos/owner/repo/check-runs/1/annotations")
+
+	// assert
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestRateLimitTransportWaitsForResetWithinReserve(t *testing.T) {
+	// arrange
+	reset := time.Now().Add(2 * time.Second).Truncate(time.Second)
+	client, rateLimiter, url := newRateLimitTestClient(t, 10, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "10")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusOK)
+	})
+	resp, err := client.Get(url + "/repos/owner/repo/check-runs/1/annotations")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// act
+	resp, err = client.Get(url + "/repos/owner/repo/check-runs/2/annotations")
+
+	// assert
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.False(t, time.Now().Before(reset))
+	limit, remaining, ok := rateLimiter.state()
+	assert.True(t, ok)
+	assert.Equal(t, 5000, limit)
+	assert.Equal(t, 10, remaining)
+}
+
+func TestRateLimitTransportDoesNotRetryPermissionErrors(t *testing.T) {
+	// arrange
+	var calls atomic.Int32
+	client, _, url := newRateLimitTestClient(t, 0, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+	})
+
+	// act
+	resp, err := client.Get(url + "/repos/owner/repo/check-runs/1/annotations")
+
+	// assert
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"message":"Resource not accessible by integration"}`, string(body))
+}
+
+func TestRateLimitTransportPausesOnSecondaryRateLimit(t *testing.T) {
+	// arrange
+	client, _, url := newRateLimitTestClient(t, 0, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/repos/owner/repo/check-runs/1/annotations", nil)
+	require.NoError(t, err)
+
+	// act
+	_, err = client.Do(req)
+
+	// assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRateLimitTransportContextReserve(t *testing.T) {
+	// arrange
+	client, _, url := newRateLimitTestClient(t, 0, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "10")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusOK)
+	})
+	resp, err := client.Get(url + "/repos/owner/repo/check-runs/1/annotations")
+	require.NoError(t, err)
+	resp.Body.Close()
+	ctx, cancel := context.WithTimeout(withRateLimitReserve(context.Background(), 100), 100*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/repos/owner/repo/actions/runs", nil)
+	require.NoError(t, err)
+
+	// act
+	_, reservedErr := client.Do(req)
+	resp, err = client.Get(url + "/repos/owner/repo/check-runs/2/annotations")
+
+	// assert
+	assert.ErrorIs(t, reservedErr, context.DeadlineExceeded)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestRateLimitTransportPauseIsCancelled(t *testing.T) {
+	// arrange
+	client, _, url := newRateLimitTestClient(t, 0, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/repos/owner/repo/check-runs/1/annotations", nil)
+	require.NoError(t, err)
+
+	// act
+	_, err = client.Do(req)
+
+	// assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
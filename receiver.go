@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -14,13 +15,18 @@ import (
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/receiver"
 	"go.opentelemetry.io/collector/receiver/receiverhelper"
-
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
 
-func newLogsReceiver(cfg *Config, params receiver.CreateSettings, consumer consumer.Logs) (*githubactionsannotationsreceiver, error) {
+const connectivityCheckTimeout = 30 * time.Second
+
+// newReceiver creates a receiver without consumers, they are set by the factory for each signal
+func newReceiver(cfg *Config, params receiver.CreateSettings) (*githubactionsannotationsreceiver, error) {
 	obsrecv, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
 		ReceiverID:             params.ID,
 		Transport:              "http",
@@ -29,47 +35,140 @@ func newLogsReceiver(cfg *Config, params receiver.CreateSettings, consumer consu
 	if err != nil {
 		return nil, err
 	}
-	ghClient, err := createGitHubClient(cfg.GitHubAuth)
+	filter, err := newJobFilter(cfg.Filters)
 	if err != nil {
 		return nil, err
 	}
-	rateLimit, _, err := ghClient.RateLimit.Get(context.Background())
+	annotationFilter, err := newAnnotationFilter(cfg.Filters.Annotations)
 	if err != nil {
 		return nil, err
 	}
-	params.Logger.Info("GitHub API rate limit", zap.Int("limit", rateLimit.GetCore().Limit), zap.Int("remaining", rateLimit.GetCore().Remaining), zap.Time("reset", rateLimit.GetCore().Reset.Time))
-	return &githubactionsannotationsreceiver{
-		config:   cfg,
-		consumer: consumer,
-		settings: params,
-		logger:   params.Logger,
-		ghClient: ghClient,
-		obsrecv:  obsrecv,
-	}, nil
+	ghClients, err := newGitHubClients(cfg.GitHubAuth, cfg.RateLimit, params.Logger)
+	if err != nil {
+		return nil, err
+	}
+	rec := &githubactionsannotationsreceiver{
+		config:           cfg,
+		webhookSecrets:   newWebhookSecrets(cfg),
+		settings:         params,
+		logger:           params.Logger,
+		ghClients:        ghClients,
+		filter:           filter,
+		annotationFilter: annotationFilter,
+		obsrecv:          obsrecv,
+	}
+	ghClients.retry = rec.retryGitHubCall
+	return rec, nil
 }
 
 type githubactionsannotationsreceiver struct {
-	config   *Config
-	consumer consumer.Logs
-	server   *http.Server
-	settings receiver.CreateSettings
-	logger   *zap.Logger
-	ghClient *github.Client
-	obsrecv  *receiverhelper.ObsReport
+	config           *Config
+	webhookSecrets   webhookSecrets
+	logsConsumer     consumer.Logs
+	tracesConsumer   consumer.Traces
+	metricsConsumer  consumer.Metrics
+	server           *http.Server
+	settings         receiver.CreateSettings
+	logger           *zap.Logger
+	ghClients        *githubClients
+	filter           *jobFilter
+	annotationFilter *annotationFilter
+	obsrecv          *receiverhelper.ObsReport
+	queue            *workQueue
+	dedup            *dedupCache
+	store            *pendingStore
+	// replay holds the items persisted by a previous run, processed before the queue
+	replay    chan workItem
+	telemetry *receiverTelemetry
+	// ctx is cancelled on shutdown to abort in-flight webhook processing
+	ctx    context.Context
+	cancel context.CancelFunc
+	// stopCollectors cancels the backfill and the polling as soon as the shutdown starts
+	stopCollectors context.CancelFunc
+	workersWg      sync.WaitGroup
+	// the receiver is shared by the logs, traces and metrics pipelines, but started and stopped once
+	startOnce    sync.Once
+	startErr     error
+	shutdownOnce sync.Once
+	shutdownErr  error
 }
 
 func (rec *githubactionsannotationsreceiver) Start(ctx context.Context, host component.Host) error {
+	rec.startOnce.Do(func() {
+		rec.startErr = rec.start(ctx, host)
+	})
+	return rec.startErr
+}
+
+// start opens the listener last, so that a failed start leaves nothing to shut down
+func (rec *githubactionsannotationsreceiver) start(ctx context.Context, host component.Host) error {
 	endpoint := fmt.Sprintf("%s%s", rec.config.ServerConfig.Endpoint, rec.config.Path)
 	rec.logger.Info("Starting receiver", zap.String("endpoint", endpoint))
-	listener, err := rec.config.ServerConfig.ToListener(ctx)
+	rec.queue = newWorkQueue(rec.config.Queue.QueueSize, rec.config.Queue.OverflowPolicy)
+	rec.dedup = newDedupCache(rec.config.Dedup)
+	var err error
+	rec.telemetry, err = newReceiverTelemetry(rec.settings.TelemetrySettings.MeterProvider, rec.queue, rec.ghClients)
 	if err != nil {
 		return err
 	}
+	rec.store, err = newPendingStore(ctx, host, rec.config.StorageID, rec.settings.ID)
+	if err != nil {
+		return multierr.Append(err, rec.telemetry.shutdown())
+	}
+	pending, err := rec.store.load(ctx)
+	if err != nil {
+		return multierr.Combine(err, rec.store.close(ctx), rec.telemetry.shutdown())
+	}
 	router := httprouter.New()
 	router.POST(rec.config.Path, rec.handleEvent)
-	rec.server, err = rec.config.ServerConfig.ToServer(ctx, host, rec.settings.TelemetrySettings, router)
+	server, err := rec.config.ServerConfig.ToServer(ctx, host, rec.settings.TelemetrySettings, router)
 	if err != nil {
-		return err
+		return multierr.Combine(err, rec.store.close(ctx), rec.telemetry.shutdown())
+	}
+	listener, err := rec.config.ServerConfig.ToListener(ctx)
+	if err != nil {
+		return multierr.Combine(err, rec.store.close(ctx), rec.telemetry.shutdown())
+	}
+	rec.server = server
+	rec.replay = make(chan workItem, len(pending))
+	for _, item := range pending {
+		rec.dedup.addIfAbsent(item.dedupKeys...)
+		rec.replay <- item
+	}
+	close(rec.replay)
+	if len(pending) > 0 {
+		rec.logger.Info("Replaying webhook events persisted by a previous run", zap.Int("count", len(pending)))
+	}
+	rec.ctx, rec.cancel = context.WithCancel(context.Background())
+	numWorkers := rec.config.Queue.NumWorkers
+	if numWorkers == 0 {
+		numWorkers = defaultQueueNumWorkers
+	}
+	for i := 0; i < numWorkers; i++ {
+		rec.workersWg.Add(1)
+		go rec.runWorker()
+	}
+	rec.workersWg.Add(1)
+	go func() {
+		defer rec.workersWg.Done()
+		rec.checkGitHubConnectivity(rec.ctx)
+	}()
+	var collectorsCtx context.Context
+	collectorsCtx, rec.stopCollectors = context.WithCancel(rec.ctx)
+	if len(rec.config.Backfill.Repositories) > 0 {
+		now := time.Now()
+		rec.workersWg.Add(1)
+		go func() {
+			defer rec.workersWg.Done()
+			rec.runBackfill(collectorsCtx, now)
+		}()
+	}
+	if rec.config.Polling.enabled() {
+		rec.workersWg.Add(1)
+		go func() {
+			defer rec.workersWg.Done()
+			rec.runPolling(collectorsCtx)
+		}()
 	}
 	go func() {
 		if err := rec.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -79,19 +178,83 @@ func (rec *githubactionsannotationsreceiver) Start(ctx context.Context, host com
 	return nil
 }
 
-func (rec *githubactionsannotationsreceiver) Shutdown(context.Context) error {
-	return nil
+// checkGitHubConnectivity logs the GitHub API rate limit and reports a recoverable error
+// if the GitHub API cannot be reached. It does not prevent the receiver from starting,
+// webhooks are accepted in the meantime and their processing retries the GitHub API calls.
+// A GitHub App without github_auth.installation_id checks its own authentication instead.
+func (rec *githubactionsannotationsreceiver) checkGitHubConnectivity(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, connectivityCheckTimeout)
+	defer cancel()
+	ghClient, err := rec.ghClients.forInstallation(0)
+	if errors.Is(err, errNoInstallation) {
+		var app *github.App
+		app, _, err = rec.ghClients.appClient.Apps.Get(ctx, "")
+		if err == nil {
+			rec.logger.Info("Authenticated as GitHub App", zap.String("github.app.slug", app.GetSlug()))
+		}
+	} else if err == nil {
+		var rateLimit *github.RateLimits
+		rateLimit, _, err = ghClient.RateLimit.Get(ctx)
+		if err == nil {
+			rec.logger.Info("GitHub API rate limit", zap.Int("limit", rateLimit.GetCore().Limit), zap.Int("remaining", rateLimit.GetCore().Remaining), zap.Time("reset", rateLimit.GetCore().Reset.Time))
+		}
+	}
+	if err != nil {
+		rec.logger.Warn("Failed to reach the GitHub API", zap.Error(err))
+		rec.settings.TelemetrySettings.ReportStatus(component.NewRecoverableErrorEvent(fmt.Errorf("failed to reach the GitHub API: %w", err)))
+		return
+	}
+	rec.settings.TelemetrySettings.ReportStatus(component.NewStatusEvent(component.StatusOK))
+}
+
+// Shutdown stops accepting new webhooks and waits for the queued ones to be processed.
+// If ctx expires first, the in-flight processing is cancelled, the remaining queued
+// webhooks are dropped and Shutdown waits for the workers to return.
+func (rec *githubactionsannotationsreceiver) Shutdown(ctx context.Context) error {
+	rec.shutdownOnce.Do(func() {
+		removeReceiver(rec.config)
+		rec.shutdownErr = rec.shutdown(ctx)
+	})
+	return rec.shutdownErr
+}
+
+func (rec *githubactionsannotationsreceiver) shutdown(ctx context.Context) error {
+	if rec.server == nil {
+		return nil
+	}
+	err := rec.server.Shutdown(ctx)
+	rec.stopCollectors()
+	rec.queue.close()
+	done := make(chan struct{})
+	go func() {
+		rec.workersWg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		rec.logger.Warn("Shutdown deadline exceeded, cancelling in-flight webhook processing", zap.Int("queue_size", rec.queue.size()))
+		rec.cancel()
+		<-done
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+	rec.cancel()
+	err = multierr.Append(err, rec.store.close(ctx))
+	return multierr.Append(err, rec.telemetry.shutdown())
 }
 
 func (rec *githubactionsannotationsreceiver) handleEvent(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	var payload []byte
-	var err error
-	payload, err = github.ValidatePayload(r, []byte(string(rec.config.WebhookSecret)))
+	payload, secretName, err := rec.webhookSecrets.validate(r)
 	if err != nil {
 		rec.logger.Error("Invalid payload", zap.Error(err))
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
+	if secretName != "" {
+		rec.logger.Debug("Webhook signature matched", zap.String("secret", secretName), zap.String("github.delivery", github.DeliveryID(r)))
+	}
 	event, err := github.ParseWebHook(github.WebHookType(r), payload)
 	if err != nil {
 		rec.logger.Error("Error parsing webhook", zap.Error(err))
@@ -100,8 +263,11 @@ func (rec *githubactionsannotationsreceiver) handleEvent(w http.ResponseWriter,
 	}
 	switch event := event.(type) {
 	case *github.WorkflowJobEvent:
-		ctx := context.WithoutCancel(r.Context())
-		rec.handleWorkflowJobEvent(ctx, event, w, r, nil)
+		rec.handleWorkflowJobEvent(r.Context(), event, w, r, nil)
+	case *github.WorkflowRunEvent:
+		rec.handleWorkflowRunEvent(r.Context(), event, w, r)
+	case *github.CheckRunEvent:
+		rec.handleCheckRunEvent(r.Context(), event, w, r)
 	default:
 		{
 			// TODO: avoid verbosity while running this
@@ -111,6 +277,8 @@ func (rec *githubactionsannotationsreceiver) handleEvent(w http.ResponseWriter,
 	}
 }
 
+// handleWorkflowJobEvent queues completed workflow jobs and acknowledges the webhook
+// right away, so GitHub's webhook timeout does not depend on the processing time.
 func (rec *githubactionsannotationsreceiver) handleWorkflowJobEvent(ctx context.Context, event *github.WorkflowJobEvent, w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	rec.logger.Debug("Handling workflow job event", zap.Int64("workflow_job.id", event.WorkflowJob.GetID()))
 	if event.GetAction() != "completed" {
@@ -119,53 +287,260 @@ func (rec *githubactionsannotationsreceiver) handleWorkflowJobEvent(ctx context.
 		w.WriteHeader(http.StatusOK)
 		return
 	}
-	var withWorkflowInfoFields = func(fields ...zap.Field) []zap.Field {
+	if !rec.filter.allowsJob(event.GetRepo(), event.GetWorkflowJob()) {
+		rec.logger.Debug("Skipping workflow job excluded by the filters", newWorkflowInfoFields(event)()...)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	dedupKeys := []string{jobDedupKey(event.GetWorkflowJob().GetID(), event.GetWorkflowJob().GetRunAttempt())}
+	rec.enqueue(ctx, workItem{event: event, dedupKeys: dedupKeys}, w, r)
+}
+
+// enqueue queues the item unless it was already accepted and acknowledges the webhook
+func (rec *githubactionsannotationsreceiver) enqueue(ctx context.Context, item workItem, w http.ResponseWriter, r *http.Request) {
+	withInfoFields := item.infoFields()
+	if deliveryID := github.DeliveryID(r); deliveryID != "" {
+		item.dedupKeys = append(item.dedupKeys, deliveryDedupKey(deliveryID))
+	}
+	if !rec.dedup.addIfAbsent(item.dedupKeys...) {
+		rec.logger.Debug("Skipping duplicate webhook event", withInfoFields(zap.String("github.delivery", github.DeliveryID(r)))...)
+		rec.telemetry.duplicatesSuppressed.Add(ctx, 1)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err := rec.store.add(ctx, item); err != nil {
+		// acknowledging a webhook that would not survive a restart could lose it, let GitHub redeliver it instead
+		rec.dedup.remove(item.dedupKeys...)
+		rec.logger.Error("Rejecting webhook event, failed to persist it", withInfoFields(zap.Error(err))...)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	dropped, err := rec.queue.push(item)
+	if err != nil {
+		rec.dedup.remove(item.dedupKeys...)
+		rec.removePending(item)
+		rec.logger.Error("Rejecting webhook event", withInfoFields(zap.Error(err))...)
+		rec.telemetry.queueDropped.Add(ctx, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	rec.forgetDropped(ctx, dropped)
+	rec.logger.Debug("Queued webhook event", withInfoFields(zap.Int("queue_size", rec.queue.size()))...)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// forgetDropped forgets the item dropped from the queue to make room for a new one, if any
+func (rec *githubactionsannotationsreceiver) forgetDropped(ctx context.Context, dropped *workItem) {
+	if dropped == nil {
+		return
+	}
+	rec.dedup.remove(dropped.dedupKeys...)
+	rec.removePending(*dropped)
+	rec.logger.Warn("Queue is full, dropped the oldest webhook event", dropped.infoFields()()...)
+	rec.telemetry.queueDropped.Add(ctx, 1)
+}
+
+// newWorkflowInfoFields returns a function prepending the workflow job identity to log fields
+func newWorkflowInfoFields(event *github.WorkflowJobEvent) func(fields ...zap.Field) []zap.Field {
+	return func(fields ...zap.Field) []zap.Field {
 		workflowInfoFields := []zap.Field{
 			zap.String("github.repository", event.GetRepo().GetFullName()),
-			zap.String("github.workflow_run.name", *event.GetWorkflowJob().WorkflowName),
+			zap.String("github.workflow_run.name", event.GetWorkflowJob().GetWorkflowName()),
 			zap.Int64("github.workflow_run.id", event.GetWorkflowJob().GetRunID()),
 			zap.Int("github.workflow_run.run_attempt", int(event.GetWorkflowJob().GetRunAttempt())),
+			zap.Int64("github.workflow_job.id", event.GetWorkflowJob().GetID()),
+			zap.String("github.workflow_job.name", event.GetWorkflowJob().GetName()),
 		}
 		return append(workflowInfoFields, fields...)
 	}
+}
 
-	rec.logger.Info("Starting to process webhook event", withWorkflowInfoFields()...)
-	err := rec.processWorkflowJobEvent(ctx, withWorkflowInfoFields, event)
+// runWorker processes the replayed and then the queued webhooks until the queue is closed and drained
+func (rec *githubactionsannotationsreceiver) runWorker() {
+	defer rec.workersWg.Done()
+	for item := range rec.replay {
+		rec.processWorkItem(item)
+	}
+	for item := range rec.queue.items {
+		rec.processWorkItem(item)
+	}
+}
+
+func (rec *githubactionsannotationsreceiver) processWorkItem(item workItem) {
+	withInfoFields := item.infoFields()
+	if rec.ctx.Err() != nil {
+		rec.logger.Warn("Dropping queued webhook event, the receiver is shutting down", withInfoFields(zap.Bool("persisted", rec.store != nil))...)
+		rec.dedup.remove(item.dedupKeys...)
+		return
+	}
+	rec.logger.Info("Starting to process webhook event", withInfoFields()...)
+	installation, repo := item.source()
+	var err error
+	if installation.GetID() != 0 {
+		err = rec.processEvent(item, withInfoFields)
+	} else {
+		err = rec.ghClients.retryStale(repo.GetOwner().GetLogin(), func() error { return rec.processEvent(item, withInfoFields) })
+	}
 	if err != nil {
-		rec.logger.Error("Failed to process webhook event", withWorkflowInfoFields(zap.Error(err))...)
-		w.WriteHeader(http.StatusInternalServerError)
+		rec.logger.Error("Failed to process webhook event", withInfoFields(zap.Error(err))...)
+		rec.dedup.remove(item.dedupKeys...)
+		if rec.ctx.Err() != nil {
+			// interrupted by the shutdown, keep it persisted to be replayed on the next start
+			return
+		}
+		if errors.Is(err, errGitHubRetriesExhausted) || errors.Is(err, errFetchTimeout) {
+			// GitHub is unavailable or too slow and the webhook was acknowledged, so it is not redelivered:
+			// keep it persisted and try again later
+			rec.requeueLater(item)
+			return
+		}
+	}
+	rec.removePending(item)
+}
+
+// processEvent processes the event of the item with the client of its installation
+func (rec *githubactionsannotationsreceiver) processEvent(item workItem, withInfoFields func(fields ...zap.Field) []zap.Field) error {
+	installation, repo := item.source()
+	ghClient, err := rec.ghClients.forEvent(rec.ctx, installation, repo)
+	if err != nil {
+		return err
+	}
+	switch {
+	case item.checkRunEvent != nil:
+		return rec.processCheckRunEvent(rec.ctx, ghClient, withInfoFields, item.checkRunEvent)
+	case item.workflowRunEvent != nil:
+		return rec.processWorkflowRunEvent(rec.ctx, ghClient, withInfoFields, item.workflowRunEvent)
+	default:
+		return rec.processWorkflowJobEvent(rec.ctx, ghClient, withInfoFields, item.event)
+	}
+}
+
+// requeueLater queues the item again after retry.requeue_delay, unless the receiver shuts down first
+// or the same job was processed or accepted again meanwhile. The item stays persisted until then.
+// Once requeued retry.max_requeues times, the item is dropped.
+func (rec *githubactionsannotationsreceiver) requeueLater(item workItem) {
+	delay := rec.config.Retry.RequeueDelay
+	if delay <= 0 {
+		return
+	}
+	withInfoFields := item.infoFields()
+	if item.requeues >= rec.config.Retry.MaxRequeues {
+		rec.logger.Error("Dropping webhook event, it was requeued too many times", withInfoFields(zap.Int("requeues", item.requeues))...)
+		rec.removePending(item)
+		return
+	}
+	item.requeues++
+	rec.logger.Warn("Requeuing webhook event once the delay expires", withInfoFields(zap.Duration("delay", delay), zap.Int("requeues", item.requeues))...)
+	go func() {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-rec.ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if !rec.dedup.addIfAbsent(item.dedupKeys...) {
+			// processed meanwhile, e.g. through a workflow_run webhook or the polling, which persist it under
+			// another key: forget this one so that it is not replayed on the next start
+			rec.removePending(item)
+			return
+		}
+		dropped, err := rec.queue.push(item)
+		if err != nil {
+			rec.dedup.remove(item.dedupKeys...)
+			rec.logger.Warn("Failed to requeue webhook event", withInfoFields(zap.Bool("persisted", rec.store != nil), zap.Error(err))...)
+			return
+		}
+		rec.forgetDropped(rec.ctx, dropped)
+	}()
+}
+
+func (rec *githubactionsannotationsreceiver) removePending(item workItem) {
+	if err := rec.store.remove(context.Background(), item); err != nil {
+		rec.logger.Error("Failed to remove persisted webhook event", item.infoFields()(zap.Error(err))...)
 	}
-	return
 }
 
 func (rec *githubactionsannotationsreceiver) processWorkflowJobEvent(
 	ctx context.Context,
+	ghClient *github.Client,
 	withWorkflowInfoFields func(fields ...zap.Field) []zap.Field,
 	event *github.WorkflowJobEvent,
 ) error {
-	annotations, err := getAnnotations(context.Background(), event, rec.ghClient)
+	annotations, err := rec.fetchAnnotations(ctx, ghClient, withWorkflowInfoFields, event.GetRepo(), event.GetWorkflowJob().GetID())
 	if err != nil {
-		rec.logger.Error("Failed to get job annotations", zap.Error(err))
+		return err
 	}
+	annotations = rec.filterAnnotations(ctx, annotations, withWorkflowInfoFields)
 
 	run := mapRun(event.WorkflowJob)
+	job := mapJob(event.WorkflowJob)
 	repository := mapRepository(event.GetRepo())
-	_, err = rec.processAnnotations(ctx, annotations, repository, run, withWorkflowInfoFields)
-	if err != nil {
-		return err
+	var errs error
+	if rec.logsConsumer != nil {
+		_, err = rec.processAnnotations(ctx, annotations, repository, withWorkflowInfoFields, func(logRecord *plog.LogRecord, line *checkRunAnnotation) error {
+			logLine := parseAnnotationToLogLine(run.CompletedAt, &line.CheckRunAnnotation)
+			return attachData(logRecord, rec.config.Severity, repository, run, job, mapAnnotation(line), logLine)
+		})
+		errs = multierr.Append(errs, err)
 	}
-	return nil
+	if rec.tracesConsumer != nil {
+		errs = multierr.Append(errs, rec.processJobSpan(ctx, annotations, repository, run, job, withWorkflowInfoFields))
+	}
+	if rec.metricsConsumer != nil {
+		errs = multierr.Append(errs, rec.processAnnotationMetrics(ctx, annotations, repository, run, job, withWorkflowInfoFields))
+	}
+	return errs
+}
+
+// errFetchTimeout wraps the error of an annotation fetch cancelled by fetch_timeout
+var errFetchTimeout = errors.New("fetching annotations timed out")
+
+// fetchAnnotations gets the annotations of a check run within fetch_timeout, if set. The cancellation
+// of the fetch, by the timeout or the shutdown, is logged and the annotations fetched until then are
+// reported as refused when logs are emitted. An error caused by the timeout wraps errFetchTimeout.
+func (rec *githubactionsannotationsreceiver) fetchAnnotations(ctx context.Context, ghClient *github.Client, withInfoFields func(fields ...zap.Field) []zap.Field, repo *github.Repository, checkRunID int64) ([]*checkRunAnnotation, error) {
+	if rec.config.FetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rec.config.FetchTimeout)
+		defer cancel()
+	}
+	annotations, err := rec.getAnnotations(ctx, ghClient, withInfoFields, repo, checkRunID)
+	if err != nil && ctx.Err() != nil {
+		reason := "shutdown"
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			reason = "fetch_timeout"
+		}
+		rec.logger.Warn("Fetching annotations was cancelled", withInfoFields(zap.String("reason", reason), zap.Int("fetched_annotations", len(annotations)), zap.Error(err))...)
+		if rec.logsConsumer != nil {
+			rec.obsrecv.StartLogsOp(ctx)
+			rec.obsrecv.EndLogsOp(ctx, "github-actions", len(annotations), fmt.Errorf("%s: %w", reason, ctx.Err()))
+		}
+		if reason == "fetch_timeout" {
+			err = fmt.Errorf("%w: %w", errFetchTimeout, err)
+		}
+	}
+	return annotations, err
 }
 
-func getAnnotations(ctx context.Context, ghEvent *github.WorkflowJobEvent, ghClient *github.Client) ([]*github.CheckRunAnnotation, error) {
+// getAnnotations lists all the annotations of a check run. A workflow job is the check run with the same ID.
+// Each page is retried according to the retry configuration. On error, the annotations of the pages
+// fetched so far are returned.
+func (rec *githubactionsannotationsreceiver) getAnnotations(ctx context.Context, ghClient *github.Client, withInfoFields func(fields ...zap.Field) []zap.Field, repo *github.Repository, checkRunID int64) ([]*checkRunAnnotation, error) {
 	listOpts := &github.ListOptions{
 		PerPage: 100,
 	}
-	var allAnnotations []*github.CheckRunAnnotation
+	var allAnnotations []*checkRunAnnotation
 	for {
-		annotations, response, err := ghClient.Checks.ListCheckRunAnnotations(ctx, ghEvent.GetRepo().GetOwner().GetLogin(), ghEvent.GetRepo().GetName(), ghEvent.WorkflowJob.GetID(), listOpts)
+		var annotations []*checkRunAnnotation
+		var response *github.Response
+		err := rec.retryGitHubCall(ctx, withInfoFields, func(ctx context.Context) error {
+			var err error
+			annotations, response, err = listCheckRunAnnotations(ctx, ghClient, repo.GetOwner().GetLogin(), repo.GetName(), checkRunID, listOpts)
+			return err
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to get job annotations: %w", err)
+			return allAnnotations, fmt.Errorf("failed to get job annotations: %w", err)
 		}
 		allAnnotations = append(allAnnotations, annotations...)
 		if response.NextPage == 0 {
@@ -176,7 +551,46 @@ func getAnnotations(ctx context.Context, ghEvent *github.WorkflowJobEvent, ghCli
 	return allAnnotations, nil
 }
 
-func (rec *githubactionsannotationsreceiver) processAnnotations(ctx context.Context, batch []*github.CheckRunAnnotation, repository Repository, run Run, withWorkflowInfoFields func(fields ...zap.Field) []zap.Field) (int, error) {
+// processAnnotations emits the annotations as log records, in chunks of at most batch_size records.
+// attach fills the log record of each annotation.
+func (rec *githubactionsannotationsreceiver) processAnnotations(ctx context.Context, batch []*checkRunAnnotation, repository Repository, withWorkflowInfoFields func(fields ...zap.Field) []zap.Field, attach func(logRecord *plog.LogRecord, line *checkRunAnnotation) error) (int, error) {
+	batchSize := rec.config.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(batch)
+	}
+	var consumed int
+	var errs error
+	for start := 0; start < len(batch); start += batchSize {
+		end := min(start+batchSize, len(batch))
+		count, err := rec.processAnnotationsChunk(ctx, batch[start:end], repository, withWorkflowInfoFields, attach)
+		consumed += count
+		errs = multierr.Append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return consumed, errs
+}
+
+// filterAnnotations returns the annotations allowed by the annotation filters and counts the dropped ones
+func (rec *githubactionsannotationsreceiver) filterAnnotations(ctx context.Context, batch []*checkRunAnnotation, withWorkflowInfoFields func(fields ...zap.Field) []zap.Field) []*checkRunAnnotation {
+	if rec.annotationFilter == nil {
+		return batch
+	}
+	allowed := make([]*checkRunAnnotation, 0, len(batch))
+	for _, annotation := range batch {
+		if rec.annotationFilter.allows(annotation) {
+			allowed = append(allowed, annotation)
+		}
+	}
+	if dropped := len(batch) - len(allowed); dropped > 0 {
+		rec.logger.Debug("Dropped annotations excluded by the filters", withWorkflowInfoFields(zap.Int("dropped_items", dropped))...)
+		rec.telemetry.annotationsFiltered.Add(ctx, int64(dropped))
+	}
+	return allowed
+}
+
+func (rec *githubactionsannotationsreceiver) processAnnotationsChunk(ctx context.Context, batch []*checkRunAnnotation, repository Repository, withWorkflowInfoFields func(fields ...zap.Field) []zap.Field, attach func(logRecord *plog.LogRecord, line *checkRunAnnotation) error) (int, error) {
 	logs := plog.NewLogs()
 	resourceLogs := logs.ResourceLogs().AppendEmpty()
 	resourceAttributes := resourceLogs.Resource().Attributes()
@@ -187,9 +601,8 @@ func (rec *githubactionsannotationsreceiver) processAnnotations(ctx context.Cont
 	scopeLogs := scopeLogsSlice.AppendEmpty()
 	logRecords := scopeLogs.LogRecords()
 	for _, line := range batch {
-		logLine := parseAnnotationToLogLine(run.CompletedAt, line)
 		logRecord := logRecords.AppendEmpty()
-		if err := attachData(&logRecord, repository, run, logLine); err != nil {
+		if err := attach(&logRecord, line); err != nil {
 			return 0, fmt.Errorf("failed to attach data to log record: %w", err)
 		}
 	}
@@ -204,56 +617,156 @@ func (rec *githubactionsannotationsreceiver) processAnnotations(ctx context.Cont
 		rec.logger.Info("Successfully consumed annotations", withWorkflowInfoFields(zap.Int("log_record_count", logs.LogRecordCount()))...)
 	}
 	rec.obsrecv.EndLogsOp(ctx, "github-actions", logs.LogRecordCount(), err)
-	return logs.LogRecordCount(), err
+	if err != nil {
+		return 0, err
+	}
+	return logs.LogRecordCount(), nil
 }
 
-func (rec *githubactionsannotationsreceiver) consumeLogsWithRetry(ctx context.Context, withWorkflowInfoFields func(fields ...zap.Field) []zap.Field, logs plog.Logs) error {
-	expBackoff := backoff.ExponentialBackOff{
-		MaxElapsedTime:      rec.config.Retry.MaxElapsedTime,
-		InitialInterval:     rec.config.Retry.InitialInterval,
-		MaxInterval:         rec.config.Retry.MaxInterval,
-		RandomizationFactor: backoff.DefaultRandomizationFactor,
-		Multiplier:          backoff.DefaultMultiplier,
-		Stop:                backoff.Stop,
-		Clock:               backoff.SystemClock,
+// processJobSpan emits the job span with an event for each annotation
+func (rec *githubactionsannotationsreceiver) processJobSpan(ctx context.Context, batch []*checkRunAnnotation, repository Repository, run Run, job Job, withWorkflowInfoFields func(fields ...zap.Field) []zap.Field) error {
+	traces := ptrace.NewTraces()
+	resourceSpans := traces.ResourceSpans().AppendEmpty()
+	resourceAttributes := resourceSpans.Resource().Attributes()
+	resourceAttributes.PutStr("service.name", generateServiceName(rec.config, repository.FullName))
+	resourceAttributes.PutStr("event.dataset", "github.annotations")
+	span := resourceSpans.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	if err := attachJobSpanData(&span, repository, run, job); err != nil {
+		return fmt.Errorf("failed to attach data to span: %w", err)
 	}
-	expBackoff.Reset()
+	for _, line := range batch {
+		logLine := parseAnnotationToLogLine(run.CompletedAt, &line.CheckRunAnnotation)
+		spanEvent := span.Events().AppendEmpty()
+		attachSpanEventData(&spanEvent, mapAnnotation(line), logLine)
+	}
+	rec.obsrecv.StartTracesOp(ctx)
+	err := rec.consumeTracesWithRetry(ctx, withWorkflowInfoFields, traces)
+	if err != nil {
+		rec.logger.Error("Failed to consume job span", withWorkflowInfoFields(zap.Error(err), zap.Int("span_events", span.Events().Len()))...)
+	} else {
+		rec.logger.Info("Successfully consumed job span", withWorkflowInfoFields(zap.Int("span_events", span.Events().Len()))...)
+	}
+	rec.obsrecv.EndTracesOp(ctx, "github-actions", traces.SpanCount(), err)
+	return err
+}
+
+// processAnnotationMetrics emits the annotation counters of the job
+func (rec *githubactionsannotationsreceiver) processAnnotationMetrics(ctx context.Context, batch []*checkRunAnnotation, repository Repository, run Run, job Job, withWorkflowInfoFields func(fields ...zap.Field) []zap.Field) error {
+	metrics := pmetric.NewMetrics()
+	resourceMetrics := metrics.ResourceMetrics().AppendEmpty()
+	resourceAttributes := resourceMetrics.Resource().Attributes()
+	resourceAttributes.PutStr("service.name", generateServiceName(rec.config, repository.FullName))
+	resourceAttributes.PutStr("event.dataset", "github.annotations")
+	attachAnnotationMetrics(resourceMetrics.ScopeMetrics().AppendEmpty(), batch, repository, run, job)
+	rec.obsrecv.StartMetricsOp(ctx)
+	err := rec.consumeMetricsWithRetry(ctx, withWorkflowInfoFields, metrics)
+	if err != nil {
+		rec.logger.Error("Failed to consume annotation metrics", withWorkflowInfoFields(zap.Error(err), zap.Int("dropped_items", metrics.DataPointCount()))...)
+	} else {
+		rec.logger.Info("Successfully consumed annotation metrics", withWorkflowInfoFields(zap.Int("data_point_count", metrics.DataPointCount()))...)
+	}
+	rec.obsrecv.EndMetricsOp(ctx, "github-actions", metrics.DataPointCount(), err)
+	return err
+}
+
+func (rec *githubactionsannotationsreceiver) consumeLogsWithRetry(ctx context.Context, withWorkflowInfoFields func(fields ...zap.Field) []zap.Field, logs plog.Logs) error {
 	retryableErr := consumererror.Logs{}
+	return rec.consumeWithRetry(ctx, withWorkflowInfoFields, "logs", func() int { return logs.LogRecordCount() }, func(ctx context.Context) error {
+		err := rec.logsConsumer.ConsumeLogs(ctx, logs)
+		if errors.As(err, &retryableErr) {
+			logs = retryableErr.Data()
+		}
+		return err
+	})
+}
+
+func (rec *githubactionsannotationsreceiver) consumeTracesWithRetry(ctx context.Context, withWorkflowInfoFields func(fields ...zap.Field) []zap.Field, traces ptrace.Traces) error {
+	retryableErr := consumererror.Traces{}
+	return rec.consumeWithRetry(ctx, withWorkflowInfoFields, "traces", func() int { return traces.SpanCount() }, func(ctx context.Context) error {
+		err := rec.tracesConsumer.ConsumeTraces(ctx, traces)
+		if errors.As(err, &retryableErr) {
+			traces = retryableErr.Data()
+		}
+		return err
+	})
+}
+
+func (rec *githubactionsannotationsreceiver) consumeMetricsWithRetry(ctx context.Context, withWorkflowInfoFields func(fields ...zap.Field) []zap.Field, metrics pmetric.Metrics) error {
+	retryableErr := consumererror.Metrics{}
+	return rec.consumeWithRetry(ctx, withWorkflowInfoFields, "metrics", func() int { return metrics.DataPointCount() }, func(ctx context.Context) error {
+		err := rec.metricsConsumer.ConsumeMetrics(ctx, metrics)
+		if errors.As(err, &retryableErr) {
+			metrics = retryableErr.Data()
+		}
+		return err
+	})
+}
+
+// consumeWithRetry calls consume until it succeeds, fails with a permanent error or the retry
+// max elapsed time expires. consume replaces its data with the failed part of retryable errors
+// and itemCount returns the number of items left to consume.
+func (rec *githubactionsannotationsreceiver) consumeWithRetry(ctx context.Context, withWorkflowInfoFields func(fields ...zap.Field) []zap.Field, signal string, itemCount func() int, consume func(ctx context.Context) error) error {
+	expBackoff := newExponentialBackOff(rec.config.Retry)
 	for {
-		err := rec.consumer.ConsumeLogs(ctx, logs)
+		err := consume(ctx)
 		if err == nil {
 			return nil
 		}
 		if consumererror.IsPermanent(err) {
 			rec.logger.Error(
-				"Consuming logs failed. The error is not retryable. Dropping data.",
+				fmt.Sprintf("Consuming %s failed. The error is not retryable. Dropping data.", signal),
 				withWorkflowInfoFields(
 					zap.Error(err),
-					zap.Int("dropped_items", logs.LogRecordCount()),
+					zap.Int("dropped_items", itemCount()),
 				)...,
 			)
 			return err
 		}
-		if errors.As(err, &retryableErr) {
-			logs = retryableErr.Data()
-		}
 		backoffDelay := expBackoff.NextBackOff()
 		if backoffDelay == backoff.Stop {
 			rec.logger.Error(
 				"Max elapsed time expired. Dropping data.",
 				withWorkflowInfoFields(
 					zap.Error(err),
-					zap.Int("dropped_items", logs.LogRecordCount()),
+					zap.Int("dropped_items", itemCount()),
 				)...,
 			)
 			return err
 		}
 		rec.logger.Debug(
-			"Consuming logs failed. Will retry the request after interval.",
+			fmt.Sprintf("Consuming %s failed. Will retry the request after interval.", signal),
 			withWorkflowInfoFields(
 				zap.Error(err),
 				zap.String("interval", backoffDelay.String()),
-				zap.Int("logs_count", logs.LogRecordCount()),
+				zap.Int(fmt.Sprintf("%s_count", signal), itemCount()),
+			)...,
+		)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context is cancelled or timed out %w", err)
+		case <-time.After(backoffDelay):
+		}
+	}
+}
+
+// retryGitHubCall calls the GitHub API until it succeeds, fails with a permanent error or the retry
+// max elapsed time expires, in which case the last error is wrapped with errGitHubRetriesExhausted
+func (rec *githubactionsannotationsreceiver) retryGitHubCall(ctx context.Context, withInfoFields func(fields ...zap.Field) []zap.Field, call func(ctx context.Context) error) error {
+	expBackoff := newExponentialBackOff(rec.config.Retry)
+	for {
+		err := call(ctx)
+		if err == nil || !isRetryableGitHubError(err) {
+			return err
+		}
+		backoffDelay := expBackoff.NextBackOff()
+		if backoffDelay == backoff.Stop {
+			return fmt.Errorf("%w: %w", errGitHubRetriesExhausted, err)
+		}
+		rec.logger.Debug(
+			"GitHub API call failed. Will retry the request after interval.",
+			withInfoFields(
+				zap.Error(err),
+				zap.String("interval", backoffDelay.String()),
 			)...,
 		)
 		select {
@@ -263,3 +776,17 @@ func (rec *githubactionsannotationsreceiver) consumeLogsWithRetry(ctx context.Co
 		}
 	}
 }
+
+func newExponentialBackOff(cfg RetryConfig) *backoff.ExponentialBackOff {
+	expBackoff := &backoff.ExponentialBackOff{
+		MaxElapsedTime:      cfg.MaxElapsedTime,
+		InitialInterval:     cfg.InitialInterval,
+		MaxInterval:         cfg.MaxInterval,
+		RandomizationFactor: backoff.DefaultRandomizationFactor,
+		Multiplier:          backoff.DefaultMultiplier,
+		Stop:                backoff.Stop,
+		Clock:               backoff.SystemClock,
+	}
+	expBackoff.Reset()
+	return expBackoff
+}
@@ -0,0 +1,1081 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/configtelemetry"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const testWebhookSecret = "secret"
+
+// getAvailableLocalAddress finds an available local port and returns an endpoint
+// describing it. The port is free at the time of the call but it is not reserved.
+func getAvailableLocalAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// newGitHubTestServer starts a fake GitHub API returning two completed jobs for every
+// workflow run and a single annotation for every check run
+func newGitHubTestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rate_limit" {
+			fmt.Fprint(w, `{"resources":{"core":{"limit":5000,"remaining":4999,"reset":1700000000}}}`)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/jobs") {
+			jobs := []*github.WorkflowJob{newWorkflowJobEvent(1).GetWorkflowJob(), newWorkflowJobEvent(2).GetWorkflowJob()}
+			require.NoError(t, json.NewEncoder(w).Encode(&github.Jobs{TotalCount: github.Int(len(jobs)), Jobs: jobs}))
+			return
+		}
+		fmt.Fprint(w, `[{"path":"main.go","start_line":1,"annotation_level":"failure","message":"boom"}]`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestGitHubClient returns a client sending its requests to ghServer
+func newTestGitHubClient(t *testing.T, ghServer *httptest.Server) *github.Client {
+	ghClient := github.NewClient(nil)
+	var err error
+	ghClient.BaseURL, err = url.Parse(ghServer.URL + "/")
+	require.NoError(t, err)
+	return ghClient
+}
+
+func newTestReceiver(t *testing.T, cfg *Config, nextConsumer consumer.Logs, ghServer *httptest.Server) *githubactionsannotationsreceiver {
+	params := receivertest.NewNopCreateSettings()
+	obsrecv, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             params.ID,
+		Transport:              "http",
+		ReceiverCreateSettings: params,
+	})
+	require.NoError(t, err)
+	return &githubactionsannotationsreceiver{
+		config:         cfg,
+		webhookSecrets: newWebhookSecrets(cfg),
+		logsConsumer:   nextConsumer,
+		settings:       params,
+		logger:         params.Logger,
+		ghClients:      &githubClients{clients: map[int64]*installationClient{0: {client: newTestGitHubClient(t, ghServer)}}},
+		obsrecv:        obsrecv,
+	}
+}
+
+func newTestConfig(t *testing.T) *Config {
+	cfg := createDefaultConfig().(*Config)
+	cfg.ServerConfig = confighttp.ServerConfig{Endpoint: getAvailableLocalAddress(t)}
+	cfg.WebhookSecret = testWebhookSecret
+	cfg.GitHubAuth.Token = "token"
+	return cfg
+}
+
+func newWorkflowJobEvent(jobID int64) *github.WorkflowJobEvent {
+	now := github.Timestamp{Time: time.Now()}
+	return &github.WorkflowJobEvent{
+		Action: github.String("completed"),
+		WorkflowJob: &github.WorkflowJob{
+			ID:           github.Int64(jobID),
+			RunID:        github.Int64(1),
+			RunAttempt:   github.Int64(1),
+			RunURL:       github.String("https://api.github.com/repos/owner/repo/actions/runs/1"),
+			Status:       github.String("completed"),
+			Conclusion:   github.String("failure"),
+			WorkflowName: github.String("CI"),
+			Name:         github.String("build"),
+			StartedAt:    &now,
+			CreatedAt:    &now,
+			CompletedAt:  &now,
+		},
+		Repo: &github.Repository{
+			FullName: github.String("owner/repo"),
+			Name:     github.String("repo"),
+			Owner:    &github.User{Login: github.String("owner")},
+		},
+	}
+}
+
+// sendWebhook posts a signed webhook to the receiver and returns the response status code
+func sendWebhook(t *testing.T, cfg *Config, eventType string, event any) int {
+	statusCode, err := postWebhook(cfg, eventType, event)
+	require.NoError(t, err)
+	return statusCode
+}
+
+// postWebhook posts a signed webhook to the receiver. Unlike sendWebhook, it can be called from any goroutine.
+func postWebhook(cfg *Config, eventType string, event any) (int, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write(payload)
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://%s%s", cfg.Endpoint, cfg.Path), bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(github.EventTypeHeader, eventType)
+	req.Header.Set(github.SHA256SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// sendWebhookAsync posts a signed webhook from another goroutine, the returned channel receives its error
+func sendWebhookAsync(cfg *Config, eventType string, event any) <-chan error {
+	sent := make(chan error, 1)
+	go func() {
+		_, err := postWebhook(cfg, eventType, event)
+		sent <- err
+	}()
+	return sent
+}
+
+func TestShutdownWithoutStart(t *testing.T) {
+	rec := newTestReceiver(t, newTestConfig(t), consumertest.NewNop(), newGitHubTestServer(t))
+
+	assert.NoError(t, rec.Shutdown(context.Background()))
+}
+
+func TestShutdownClosesListener(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), newGitHubTestServer(t))
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+	require.Equal(t, http.StatusOK, sendWebhook(t, cfg, "ping", &github.PingEvent{}))
+
+	// act
+	err := rec.Shutdown(context.Background())
+
+	// assert
+	assert.NoError(t, err)
+	_, err = net.Dial("tcp", cfg.Endpoint)
+	assert.Error(t, err)
+}
+
+func TestShutdownWaitsForInFlightProcessing(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	consuming := make(chan struct{})
+	release := make(chan struct{})
+	sink := new(consumertest.LogsSink)
+	nextConsumer, err := consumer.NewLogs(func(ctx context.Context, ld plog.Logs) error {
+		close(consuming)
+		<-release
+		return sink.ConsumeLogs(ctx, ld)
+	})
+	require.NoError(t, err)
+	rec := newTestReceiver(t, cfg, nextConsumer, newGitHubTestServer(t))
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+	sent := sendWebhookAsync(cfg, "workflow_job", newWorkflowJobEvent(1))
+	<-consuming
+	require.NoError(t, <-sent)
+
+	// act
+	shutdownDone := make(chan error)
+	go func() {
+		shutdownDone <- rec.Shutdown(context.Background())
+	}()
+
+	// assert
+	select {
+	case <-shutdownDone:
+		t.Fatal("Shutdown returned before in-flight processing finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	assert.NoError(t, <-shutdownDone)
+	assert.Equal(t, 1, sink.LogRecordCount())
+}
+
+func TestShutdownCancelsInFlightProcessingOnDeadline(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	consuming := make(chan struct{})
+	cancelled := make(chan struct{})
+	nextConsumer, err := consumer.NewLogs(func(ctx context.Context, _ plog.Logs) error {
+		close(consuming)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+	rec := newTestReceiver(t, cfg, nextConsumer, newGitHubTestServer(t))
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+	sent := sendWebhookAsync(cfg, "workflow_job", newWorkflowJobEvent(1))
+	<-consuming
+	require.NoError(t, <-sent)
+
+	// act
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = rec.Shutdown(ctx)
+
+	// assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	select {
+	case <-cancelled:
+	default:
+		t.Fatal("in-flight processing was not cancelled")
+	}
+}
+
+func TestWebhookIsAcknowledgedBeforeProcessing(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	release := make(chan struct{})
+	sink := new(consumertest.LogsSink)
+	nextConsumer, err := consumer.NewLogs(func(ctx context.Context, ld plog.Logs) error {
+		<-release
+		return sink.ConsumeLogs(ctx, ld)
+	})
+	require.NoError(t, err)
+	rec := newTestReceiver(t, cfg, nextConsumer, newGitHubTestServer(t))
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	// act
+	statusCode := sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1))
+
+	// assert
+	assert.Equal(t, http.StatusAccepted, statusCode)
+	assert.Equal(t, 0, sink.LogRecordCount())
+	close(release)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Equal(t, 1, sink.LogRecordCount())
+}
+
+func TestWebhookIsRejectedWhenQueueIsFull(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.Queue = QueueConfig{NumWorkers: 1, QueueSize: 1, OverflowPolicy: overflowPolicyReject}
+	consuming := make(chan struct{}, 1)
+	release := make(chan struct{})
+	nextConsumer, err := consumer.NewLogs(func(context.Context, plog.Logs) error {
+		consuming <- struct{}{}
+		<-release
+		return nil
+	})
+	require.NoError(t, err)
+	reader := sdkmetric.NewManualReader()
+	rec := newTestReceiver(t, cfg, nextConsumer, newGitHubTestServer(t))
+	rec.settings.TelemetrySettings.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+	defer func() {
+		close(release)
+		require.NoError(t, rec.Shutdown(context.Background()))
+	}()
+	// the first job keeps the only worker busy and the second one fills the queue
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+	<-consuming
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(2)))
+
+	// act
+	statusCode := sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(3))
+
+	// assert
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	values := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				values[m.Name] = data.DataPoints[0].Value
+			case metricdata.Sum[int64]:
+				values[m.Name] = data.DataPoints[0].Value
+			}
+		}
+	}
+	assert.Equal(t, int64(1), values["receiver_githubactionsannotations_queue_size"])
+	assert.Equal(t, int64(1), values["receiver_githubactionsannotations_queue_capacity"])
+	assert.Equal(t, int64(1), values["receiver_githubactionsannotations_queue_dropped"])
+}
+
+func TestDuplicateWebhookIsSuppressed(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, newGitHubTestServer(t))
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+
+	// act
+	statusCode := sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1))
+
+	// assert
+	assert.Equal(t, http.StatusOK, statusCode)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Equal(t, 1, sink.LogRecordCount())
+}
+
+func TestPersistedWebhookIsReplayedAfterRestart(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	host := newStorageHost(storageID, newMemoryStorage())
+	consuming := make(chan struct{})
+	blockingConsumer, err := consumer.NewLogs(func(ctx context.Context, _ plog.Logs) error {
+		close(consuming)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+	rec := newTestReceiver(t, cfg, blockingConsumer, newGitHubTestServer(t))
+	require.NoError(t, rec.Start(context.Background(), host))
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+	<-consuming
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, rec.Shutdown(ctx), context.DeadlineExceeded)
+
+	// act
+	sink := new(consumertest.LogsSink)
+	restarted := newTestReceiver(t, cfg, sink, newGitHubTestServer(t))
+	require.NoError(t, restarted.Start(context.Background(), host))
+	require.NoError(t, restarted.Shutdown(context.Background()))
+
+	// assert
+	assert.Equal(t, 1, sink.LogRecordCount())
+	items, err := restarted.store.load(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestWebhookIsRejectedWhenPersistingFails(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	memoryStorage := newMemoryStorage()
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, newGitHubTestServer(t))
+	require.NoError(t, rec.Start(context.Background(), newStorageHost(storageID, memoryStorage)))
+	memoryStorage.mu.Lock()
+	memoryStorage.setErr = errors.New("disk full")
+	memoryStorage.mu.Unlock()
+
+	// act
+	rejected := sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1))
+	memoryStorage.mu.Lock()
+	memoryStorage.setErr = nil
+	memoryStorage.mu.Unlock()
+	redelivered := sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1))
+
+	// assert
+	assert.Equal(t, http.StatusServiceUnavailable, rejected)
+	assert.Equal(t, http.StatusAccepted, redelivered)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Equal(t, 1, sink.LogRecordCount())
+}
+
+func TestNewReceiverDoesNotCallGitHub(t *testing.T) {
+	// arrange
+	cfg := createDefaultConfig().(*Config)
+	cfg.GitHubAuth = GitHubAuth{
+		Token:   "token",
+		BaseURL: "http://" + getAvailableLocalAddress(t),
+	}
+
+	// act
+	rec, err := newReceiver(cfg, receivertest.NewNopCreateSettings())
+
+	// assert
+	assert.NoError(t, err)
+	assert.NotNil(t, rec)
+}
+
+func TestStartReportsUnreachableGitHub(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	ghServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ghServer.Close()
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), ghServer)
+	events := make(chan *component.StatusEvent, 1)
+	rec.settings.TelemetrySettings.ReportStatus = func(event *component.StatusEvent) {
+		events <- event
+	}
+
+	// act
+	err := rec.Start(context.Background(), componenttest.NewNopHost())
+
+	// assert
+	assert.NoError(t, err)
+	event := <-events
+	assert.Equal(t, component.StatusRecoverableError, event.Status())
+	assert.ErrorContains(t, event.Err(), "failed to reach the GitHub API")
+	assert.NoError(t, rec.Shutdown(context.Background()))
+}
+
+func TestStartReportsReachableGitHub(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), newGitHubTestServer(t))
+	events := make(chan *component.StatusEvent, 1)
+	rec.settings.TelemetrySettings.ReportStatus = func(event *component.StatusEvent) {
+		events <- event
+	}
+
+	// act
+	err := rec.Start(context.Background(), componenttest.NewNopHost())
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, component.StatusOK, (<-events).Status())
+	assert.NoError(t, rec.Shutdown(context.Background()))
+}
+
+func TestProcessAnnotationsHonorsBatchSize(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.BatchSize = 2
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, newGitHubTestServer(t))
+	batch := make([]*checkRunAnnotation, 5)
+	for i := range batch {
+		batch[i] = newTestAnnotation("main.go", "warning")
+	}
+	event := newWorkflowJobEvent(1)
+
+	// act
+	count, err := rec.processAnnotations(context.Background(), batch, mapRepository(event.GetRepo()), newWorkflowInfoFields(event), func(logRecord *plog.LogRecord, line *checkRunAnnotation) error {
+		logRecord.Body().SetStr(line.GetMessage())
+		return nil
+	})
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+	require.Len(t, sink.AllLogs(), 3)
+	assert.Equal(t, 2, sink.AllLogs()[0].LogRecordCount())
+	assert.Equal(t, 2, sink.AllLogs()[1].LogRecordCount())
+	assert.Equal(t, 1, sink.AllLogs()[2].LogRecordCount())
+}
+
+func newCheckRunEvent(checkRunID int64, appSlug string) *github.CheckRunEvent {
+	now := github.Timestamp{Time: time.Now()}
+	return &github.CheckRunEvent{
+		Action: github.String("completed"),
+		CheckRun: &github.CheckRun{
+			ID:          github.Int64(checkRunID),
+			Name:        github.String("CodeQL"),
+			HeadSHA:     github.String("abc123"),
+			Status:      github.String("completed"),
+			Conclusion:  github.String("neutral"),
+			StartedAt:   &now,
+			CompletedAt: &now,
+			App: &github.App{
+				Slug: github.String(appSlug),
+				Name: github.String("App " + appSlug),
+			},
+		},
+		Repo: &github.Repository{
+			FullName: github.String("owner/repo"),
+			Name:     github.String("repo"),
+			Owner:    &github.User{Login: github.String("owner")},
+		},
+	}
+}
+
+func TestCheckRunEventIsCollectedForAllowedApps(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.CheckRuns.Apps = []string{"github-advanced-security"}
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, newGitHubTestServer(t))
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	// act
+	allowed := sendWebhook(t, cfg, "check_run", newCheckRunEvent(1, "github-advanced-security"))
+	notAllowed := sendWebhook(t, cfg, "check_run", newCheckRunEvent(2, "other-app"))
+	githubActions := sendWebhook(t, cfg, "check_run", newCheckRunEvent(3, "github-actions"))
+
+	// assert
+	assert.Equal(t, http.StatusAccepted, allowed)
+	assert.Equal(t, http.StatusOK, notAllowed)
+	assert.Equal(t, http.StatusOK, githubActions)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	require.Equal(t, 1, sink.LogRecordCount())
+	logRecord := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	attrs := logRecord.Attributes().AsRaw()
+	assert.Equal(t, int64(1), attrs["github.check_run.id"])
+	assert.Equal(t, "github-advanced-security", attrs["github.app.slug"])
+	assert.Equal(t, "App github-advanced-security", attrs["github.app.name"])
+	assert.Equal(t, "boom", logRecord.Body().Str())
+	assert.Equal(t, plog.SeverityNumberError, logRecord.SeverityNumber())
+}
+
+func TestCheckRunEventIsFilteredOnCheckSuiteBranch(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.CheckRuns.Apps = []string{"*"}
+	cfg.Filters.Branches = FilterConfig{Include: []string{"main"}}
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), newGitHubTestServer(t))
+	var err error
+	rec.filter, err = newJobFilter(cfg.Filters)
+	require.NoError(t, err)
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+	onMain := newCheckRunEvent(1, "github-advanced-security")
+	onMain.CheckRun.CheckSuite = &github.CheckSuite{HeadBranch: github.String("main")}
+	onFeature := newCheckRunEvent(2, "github-advanced-security")
+	onFeature.CheckRun.CheckSuite = &github.CheckSuite{HeadBranch: github.String("feature")}
+
+	// act
+	allowed := sendWebhook(t, cfg, "check_run", onMain)
+	filtered := sendWebhook(t, cfg, "check_run", onFeature)
+
+	// assert
+	assert.Equal(t, http.StatusAccepted, allowed)
+	assert.Equal(t, http.StatusOK, filtered)
+	require.NoError(t, rec.Shutdown(context.Background()))
+}
+
+func TestCheckRunEventIsIgnoredByDefault(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, newGitHubTestServer(t))
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	// act
+	statusCode := sendWebhook(t, cfg, "check_run", newCheckRunEvent(1, "github-advanced-security"))
+
+	// assert
+	assert.Equal(t, http.StatusOK, statusCode)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Equal(t, 0, sink.LogRecordCount())
+}
+
+func newWorkflowRunEvent(runID int64) *github.WorkflowRunEvent {
+	return &github.WorkflowRunEvent{
+		Action: github.String("completed"),
+		WorkflowRun: &github.WorkflowRun{
+			ID:         github.Int64(runID),
+			Name:       github.String("CI"),
+			RunAttempt: github.Int(1),
+			HeadBranch: github.String("main"),
+			Status:     github.String("completed"),
+			Conclusion: github.String("failure"),
+		},
+		Repo: &github.Repository{
+			FullName: github.String("owner/repo"),
+			Name:     github.String("repo"),
+			Owner:    &github.User{Login: github.String("owner")},
+		},
+	}
+}
+
+func TestWorkflowRunEventCollectsAnnotationsOfAllJobs(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, newGitHubTestServer(t))
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	// act
+	statusCode := sendWebhook(t, cfg, "workflow_run", newWorkflowRunEvent(1))
+
+	// assert
+	assert.Equal(t, http.StatusAccepted, statusCode)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	require.Equal(t, 2, sink.LogRecordCount())
+	var jobIDs []any
+	for _, logs := range sink.AllLogs() {
+		attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+		jobIDs = append(jobIDs, attrs["github.workflow_job.id"])
+		assert.Equal(t, int64(1), attrs["github.workflow_run.id"])
+	}
+	assert.ElementsMatch(t, []any{int64(1), int64(2)}, jobIDs)
+}
+
+func TestWorkflowRunEventSkipsJobsProcessedFromWorkflowJobEvents(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, newGitHubTestServer(t))
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+
+	// act
+	runStatusCode := sendWebhook(t, cfg, "workflow_run", newWorkflowRunEvent(1))
+	require.Eventually(t, func() bool { return sink.LogRecordCount() == 2 }, 5*time.Second, 10*time.Millisecond)
+	jobStatusCode := sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(2))
+
+	// assert
+	assert.Equal(t, http.StatusAccepted, runStatusCode)
+	assert.Equal(t, http.StatusOK, jobStatusCode)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Equal(t, 2, sink.LogRecordCount())
+}
+
+// newFailingGitHubTestServer starts a fake GitHub API answering the annotation requests with
+// the status codes returned by statusCode for each call, and with a single annotation on 200
+func newFailingGitHubTestServer(t *testing.T, statusCode func(call int) int) (*httptest.Server, func() int) {
+	var mu sync.Mutex
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rate_limit" {
+			fmt.Fprint(w, `{"resources":{"core":{"limit":5000,"remaining":4999,"reset":1700000000}}}`)
+			return
+		}
+		mu.Lock()
+		calls++
+		code := statusCode(calls)
+		mu.Unlock()
+		w.WriteHeader(code)
+		if code == http.StatusOK {
+			fmt.Fprint(w, `[{"path":"main.go","start_line":1,"annotation_level":"failure","message":"boom"}]`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+func TestAnnotationFetchIsRetriedOnServerErrors(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.Retry = RetryConfig{InitialInterval: time.Millisecond, MaxInterval: 10 * time.Millisecond, MaxElapsedTime: time.Second}
+	ghServer, calls := newFailingGitHubTestServer(t, func(call int) int {
+		if call <= 2 {
+			return http.StatusBadGateway
+		}
+		return http.StatusOK
+	})
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, ghServer)
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	// act
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+
+	// assert
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Equal(t, 3, calls())
+	assert.Equal(t, 1, sink.LogRecordCount())
+}
+
+func TestAnnotationFetchIsNotRetriedOnPermanentErrors(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.Retry = RetryConfig{InitialInterval: time.Millisecond, MaxInterval: 10 * time.Millisecond, MaxElapsedTime: time.Second}
+	ghServer, calls := newFailingGitHubTestServer(t, func(int) int { return http.StatusNotFound })
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, ghServer)
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	// act
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+
+	// assert
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Equal(t, 1, calls())
+	assert.Equal(t, 0, sink.LogRecordCount())
+}
+
+func TestAnnotationFetchRetriesExhaustedKeepsWebhookForRedelivery(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.Retry = RetryConfig{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, MaxElapsedTime: 20 * time.Millisecond}
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	host := newStorageHost(storageID, newMemoryStorage())
+	ghServer, calls := newFailingGitHubTestServer(t, func(int) int { return http.StatusServiceUnavailable })
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, ghServer)
+	require.NoError(t, rec.Start(context.Background(), host))
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+	// the failed job is forgotten by the deduplication once the retries are exhausted
+	require.Eventually(t, func() bool { return rec.dedup.addIfAbsent(jobDedupKey(1, 1)) }, 5*time.Second, 10*time.Millisecond)
+	rec.dedup.remove(jobDedupKey(1, 1))
+
+	// act
+	statusCode := sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1))
+
+	// assert
+	assert.Equal(t, http.StatusAccepted, statusCode)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Greater(t, calls(), 2)
+	assert.Equal(t, 0, sink.LogRecordCount())
+	items, err := rec.store.load(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestAnnotationFetchRetriesExhaustedRequeuesWebhook(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.Retry = RetryConfig{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, MaxElapsedTime: 20 * time.Millisecond, RequeueDelay: 50 * time.Millisecond, MaxRequeues: 1}
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	host := newStorageHost(storageID, newMemoryStorage())
+	var recovered atomic.Bool
+	ghServer, _ := newFailingGitHubTestServer(t, func(int) int {
+		if recovered.Load() {
+			return http.StatusOK
+		}
+		return http.StatusServiceUnavailable
+	})
+	core, logs := observer.New(zap.WarnLevel)
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, ghServer)
+	rec.logger = zap.New(core)
+	require.NoError(t, rec.Start(context.Background(), host))
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+	require.Eventually(t, func() bool { return logs.FilterMessage("Requeuing webhook event once the delay expires").Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// act
+	recovered.Store(true)
+
+	// assert
+	require.Eventually(t, func() bool { return sink.LogRecordCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	items, err := rec.store.load(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestRequeuedWebhookIsDroppedAfterMaxRequeues(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.Retry = RetryConfig{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, MaxElapsedTime: 10 * time.Millisecond, RequeueDelay: 10 * time.Millisecond, MaxRequeues: 2}
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	host := newStorageHost(storageID, newMemoryStorage())
+	ghServer, _ := newFailingGitHubTestServer(t, func(int) int { return http.StatusServiceUnavailable })
+	core, logs := observer.New(zap.WarnLevel)
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), ghServer)
+	rec.logger = zap.New(core)
+	require.NoError(t, rec.Start(context.Background(), host))
+
+	// act
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+
+	// assert
+	require.Eventually(t, func() bool {
+		return logs.FilterMessage("Dropping webhook event, it was requeued too many times").Len() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Equal(t, 2, logs.FilterMessage("Requeuing webhook event once the delay expires").Len())
+	items, err := rec.store.load(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestRequeuedWebhookProcessedMeanwhileIsForgotten(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.Retry = RetryConfig{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, MaxElapsedTime: 10 * time.Millisecond, RequeueDelay: 100 * time.Millisecond, MaxRequeues: 1}
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	host := newStorageHost(storageID, newMemoryStorage())
+	ghServer, calls := newFailingGitHubTestServer(t, func(int) int { return http.StatusServiceUnavailable })
+	core, logs := observer.New(zap.WarnLevel)
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), ghServer)
+	rec.logger = zap.New(core)
+	require.NoError(t, rec.Start(context.Background(), host))
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+	require.Eventually(t, func() bool { return logs.FilterMessage("Requeuing webhook event once the delay expires").Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+	failedCalls := calls()
+
+	// act
+	// the job is collected meanwhile, e.g. by the polling
+	require.True(t, rec.dedup.addIfAbsent(jobDedupKey(1, 1)))
+
+	// assert
+	require.Eventually(t, func() bool {
+		items, err := rec.store.load(context.Background())
+		return err == nil && len(items) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Equal(t, failedCalls, calls())
+}
+
+// newBlockingGitHubTestServer starts a fake GitHub API answering the annotation requests only once they are cancelled
+func newBlockingGitHubTestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rate_limit" {
+			fmt.Fprint(w, `{"resources":{"core":{"limit":5000,"remaining":4999,"reset":1700000000}}}`)
+			return
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAnnotationFetchIsCancelledByFetchTimeout(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.FetchTimeout = 50 * time.Millisecond
+	core, logs := observer.New(zap.WarnLevel)
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), newBlockingGitHubTestServer(t))
+	rec.logger = zap.New(core)
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	// act
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+
+	// assert
+	require.Eventually(t, func() bool { return logs.FilterMessage("Fetching annotations was cancelled").Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "fetch_timeout", logs.FilterMessage("Fetching annotations was cancelled").All()[0].ContextMap()["reason"])
+	require.NoError(t, rec.Shutdown(context.Background()))
+}
+
+func TestAnnotationFetchTimeoutKeepsWebhookPersisted(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.FetchTimeout = 50 * time.Millisecond
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	host := newStorageHost(storageID, newMemoryStorage())
+	core, logs := observer.New(zap.WarnLevel)
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), newBlockingGitHubTestServer(t))
+	rec.logger = zap.New(core)
+	require.NoError(t, rec.Start(context.Background(), host))
+
+	// act
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+
+	// assert
+	require.Eventually(t, func() bool { return logs.FilterMessage("Requeuing webhook event once the delay expires").Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	items, err := rec.store.load(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestAnnotationFetchTimeoutWithoutLogsReportsNoLogRecords(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.FetchTimeout = 50 * time.Millisecond
+	core, logs := observer.New(zap.WarnLevel)
+	rec := newTestReceiver(t, cfg, nil, newBlockingGitHubTestServer(t))
+	rec.metricsConsumer = consumertest.NewNop()
+	rec.logger = zap.New(core)
+	reader := sdkmetric.NewManualReader()
+	rec.settings.TelemetrySettings.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	rec.settings.TelemetrySettings.MetricsLevel = configtelemetry.LevelDetailed
+	var err error
+	rec.obsrecv, err = receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             rec.settings.ID,
+		Transport:              "http",
+		ReceiverCreateSettings: rec.settings,
+	})
+	require.NoError(t, err)
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	// act
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+
+	// assert
+	require.Eventually(t, func() bool { return logs.FilterMessage("Fetching annotations was cancelled").Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			assert.NotContains(t, m.Name, "log_records")
+		}
+	}
+}
+
+func TestFailedStartReleasesListenerAndTelemetry(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	storageID := component.MustNewID("file_storage")
+	cfg.StorageID = &storageID
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), newGitHubTestServer(t))
+	reader := sdkmetric.NewManualReader()
+	rec.settings.TelemetrySettings.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	// act
+	err := rec.Start(context.Background(), componenttest.NewNopHost())
+
+	// assert
+	assert.EqualError(t, err, `storage extension "file_storage" not found`)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	listener, err := net.Listen("tcp", cfg.ServerConfig.Endpoint)
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			assert.NotEqual(t, "receiver_githubactionsannotations_queue_size", m.Name)
+		}
+	}
+}
+
+func TestShutdownCancelsAnnotationFetch(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	core, logs := observer.New(zap.WarnLevel)
+	rec := newTestReceiver(t, cfg, consumertest.NewNop(), newBlockingGitHubTestServer(t))
+	rec.logger = zap.New(core)
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+	require.Equal(t, http.StatusAccepted, sendWebhook(t, cfg, "workflow_job", newWorkflowJobEvent(1)))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// act
+	err := rec.Shutdown(ctx)
+
+	// assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 1, logs.FilterMessage("Fetching annotations was cancelled").Len())
+	assert.Equal(t, "shutdown", logs.FilterMessage("Fetching annotations was cancelled").All()[0].ContextMap()["reason"])
+}
+
+func TestWorkflowJobExcludedByFiltersIsNotFetched(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.Filters.Branches.Include = []string{"main"}
+	ghServer, calls := newFailingGitHubTestServer(t, func(int) int { return http.StatusOK })
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, ghServer)
+	var err error
+	rec.filter, err = newJobFilter(cfg.Filters)
+	require.NoError(t, err)
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+	mainJob := newWorkflowJobEvent(1)
+	mainJob.WorkflowJob.HeadBranch = github.String("main")
+	featureJob := newWorkflowJobEvent(2)
+	featureJob.WorkflowJob.HeadBranch = github.String("feature")
+
+	// act
+	mainStatusCode := sendWebhook(t, cfg, "workflow_job", mainJob)
+	featureStatusCode := sendWebhook(t, cfg, "workflow_job", featureJob)
+
+	// assert
+	assert.Equal(t, http.StatusAccepted, mainStatusCode)
+	assert.Equal(t, http.StatusOK, featureStatusCode)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Equal(t, 1, calls())
+	assert.Equal(t, 1, sink.LogRecordCount())
+}
+
+func TestProcessWorkflowJobEventDropsFilteredAnnotations(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.Filters.Annotations.MinLevel = "warning"
+	ghServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[
+			{"path":"main.go","start_line":1,"annotation_level":"notice","message":"notice"},
+			{"path":"main.go","start_line":2,"annotation_level":"warning","message":"warning"},
+			{"path":"main.go","start_line":3,"annotation_level":"notice","message":"notice"}
+		]`)
+	}))
+	t.Cleanup(ghServer.Close)
+	logsSink := new(consumertest.LogsSink)
+	tracesSink := new(consumertest.TracesSink)
+	metricsSink := new(consumertest.MetricsSink)
+	reader := sdkmetric.NewManualReader()
+	rec := newTestReceiver(t, cfg, logsSink, ghServer)
+	rec.tracesConsumer = tracesSink
+	rec.metricsConsumer = metricsSink
+	var err error
+	rec.annotationFilter, err = newAnnotationFilter(cfg.Filters.Annotations)
+	require.NoError(t, err)
+	rec.telemetry, err = newReceiverTelemetry(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)), newWorkQueue(1, overflowPolicyReject), nil)
+	require.NoError(t, err)
+	event := newWorkflowJobEvent(1)
+
+	// act
+	err = rec.processWorkflowJobEvent(context.Background(), newTestGitHubClient(t, ghServer), newWorkflowInfoFields(event), event)
+
+	// assert
+	require.NoError(t, err)
+	require.Equal(t, 1, logsSink.LogRecordCount())
+	require.Len(t, tracesSink.AllTraces(), 1)
+	assert.Equal(t, 1, tracesSink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Events().Len())
+	require.Len(t, metricsSink.AllMetrics(), 1)
+	metrics := metricsSink.AllMetrics()[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	var annotations int64
+	for i := 0; i < metrics.Len(); i++ {
+		if metrics.At(i).Name() == "github.annotations" {
+			for j := 0; j < metrics.At(i).Sum().DataPoints().Len(); j++ {
+				annotations += metrics.At(i).Sum().DataPoints().At(j).IntValue()
+			}
+		}
+	}
+	assert.Equal(t, int64(1), annotations)
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var filtered int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == "receiver_githubactionsannotations_annotations_filtered" {
+				filtered = m.Data.(metricdata.Sum[int64]).DataPoints[0].Value
+			}
+		}
+	}
+	assert.Equal(t, int64(2), filtered)
+}
+
+func TestWorkflowJobWithExcludedConclusionIsNotFetched(t *testing.T) {
+	// arrange
+	cfg := newTestConfig(t)
+	cfg.Filters.Conclusions = []string{"failure"}
+	ghServer, calls := newFailingGitHubTestServer(t, func(int) int { return http.StatusOK })
+	sink := new(consumertest.LogsSink)
+	rec := newTestReceiver(t, cfg, sink, ghServer)
+	var err error
+	rec.filter, err = newJobFilter(cfg.Filters)
+	require.NoError(t, err)
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+	succeeded := newWorkflowJobEvent(1)
+	succeeded.WorkflowJob.Conclusion = github.String("success")
+
+	// act
+	statusCode := sendWebhook(t, cfg, "workflow_job", succeeded)
+
+	// assert
+	assert.Equal(t, http.StatusOK, statusCode)
+	require.NoError(t, rec.Shutdown(context.Background()))
+	assert.Equal(t, 0, calls())
+	assert.Equal(t, 0, sink.LogRecordCount())
+}
@@ -0,0 +1,56 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+const (
+	annotationLevelNotice  = "notice"
+	annotationLevelWarning = "warning"
+	annotationLevelFailure = "failure"
+)
+
+// severityNumbers maps the OpenTelemetry severity names accepted in the
+// configuration to their severity numbers
+var severityNumbers = map[string]plog.SeverityNumber{
+	"TRACE": plog.SeverityNumberTrace,
+	"DEBUG": plog.SeverityNumberDebug,
+	"INFO":  plog.SeverityNumberInfo,
+	"WARN":  plog.SeverityNumberWarn,
+	"ERROR": plog.SeverityNumberError,
+	"FATAL": plog.SeverityNumberFatal,
+}
+
+// SeverityConfig maps each GitHub annotation level to an OpenTelemetry severity name
+type SeverityConfig struct {
+	Notice  string `mapstructure:"notice"`
+	Warning string `mapstructure:"warning"`
+	Failure string `mapstructure:"failure"`
+}
+
+func isValidSeverity(severity string) bool {
+	_, ok := severityNumbers[strings.ToUpper(severity)]
+	return ok
+}
+
+// mapSeverity returns the severity number for the given annotation level.
+// Unknown or empty levels are mapped to SeverityNumberUnspecified.
+func mapSeverity(cfg SeverityConfig, annotationLevel string) plog.SeverityNumber {
+	var severity string
+	switch strings.ToLower(annotationLevel) {
+	case annotationLevelNotice:
+		severity = cfg.Notice
+	case annotationLevelWarning:
+		severity = cfg.Warning
+	case annotationLevelFailure:
+		severity = cfg.Failure
+	default:
+		return plog.SeverityNumberUnspecified
+	}
+	if severityNumber, ok := severityNumbers[strings.ToUpper(severity)]; ok {
+		return severityNumber
+	}
+	return plog.SeverityNumberUnspecified
+}
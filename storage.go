@@ -0,0 +1,193 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+// pendingIndexKey is the storage key holding the keys of all the pending items,
+// since the storage client cannot list its keys
+const pendingIndexKey = "pending_index"
+
+// pendingItem is the persisted form of a workItem
+type pendingItem struct {
+	Event            *github.WorkflowJobEvent `json:"event,omitempty"`
+	WorkflowRunEvent *github.WorkflowRunEvent `json:"workflow_run_event,omitempty"`
+	CheckRunEvent    *github.CheckRunEvent    `json:"check_run_event,omitempty"`
+	DedupKeys        []string                 `json:"dedup_keys"`
+}
+
+// pendingStore persists the accepted webhooks until they are processed, so the ones
+// still queued or in-flight when the collector stops are replayed on the next start.
+// A nil pendingStore persists nothing.
+type pendingStore struct {
+	mu     sync.Mutex
+	client storage.Client
+	keys   map[string]struct{}
+}
+
+// newPendingStore gets a storage client from the storage extension referenced by storageID
+func newPendingStore(ctx context.Context, host component.Host, storageID *component.ID, receiverID component.ID) (*pendingStore, error) {
+	if storageID == nil {
+		return nil, nil
+	}
+	ext, ok := host.GetExtensions()[*storageID]
+	if !ok {
+		return nil, fmt.Errorf("storage extension %q not found", storageID)
+	}
+	storageExt, ok := ext.(storage.Extension)
+	if !ok {
+		return nil, fmt.Errorf("extension %q is not a storage extension", storageID)
+	}
+	client, err := storageExt.GetClient(ctx, component.KindReceiver, receiverID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage client: %w", err)
+	}
+	return &pendingStore{
+		client: client,
+		keys:   make(map[string]struct{}),
+	}, nil
+}
+
+func pendingItemKey(item workItem) string {
+	if item.checkRunEvent != nil {
+		return fmt.Sprintf("pending_check_run_%d", item.checkRunEvent.GetCheckRun().GetID())
+	}
+	if item.workflowRunEvent != nil {
+		return fmt.Sprintf("pending_workflow_run_%d_%d", item.workflowRunEvent.GetWorkflowRun().GetID(), item.workflowRunEvent.GetWorkflowRun().GetRunAttempt())
+	}
+	return fmt.Sprintf("pending_%d_%d", item.event.GetWorkflowJob().GetID(), item.event.GetWorkflowJob().GetRunAttempt())
+}
+
+// load returns the items persisted by a previous run
+func (s *pendingStore) load(ctx context.Context) ([]workItem, error) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index, err := s.client.Get(ctx, pendingIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending index: %w", err)
+	}
+	if index == nil {
+		return nil, nil
+	}
+	var keys []string
+	if err := json.Unmarshal(index, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode pending index: %w", err)
+	}
+	var items []workItem
+	for _, key := range keys {
+		data, err := s.client.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pending item %q: %w", key, err)
+		}
+		if data == nil {
+			continue
+		}
+		var persisted pendingItem
+		if err := json.Unmarshal(data, &persisted); err != nil {
+			return nil, fmt.Errorf("failed to decode pending item %q: %w", key, err)
+		}
+		s.keys[key] = struct{}{}
+		items = append(items, workItem{event: persisted.Event, workflowRunEvent: persisted.WorkflowRunEvent, checkRunEvent: persisted.CheckRunEvent, dedupKeys: persisted.DedupKeys})
+	}
+	return items, nil
+}
+
+// add persists the item until remove is called
+func (s *pendingStore) add(ctx context.Context, item workItem) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.Marshal(pendingItem{Event: item.event, WorkflowRunEvent: item.workflowRunEvent, CheckRunEvent: item.checkRunEvent, DedupKeys: item.dedupKeys})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := pendingItemKey(item)
+	s.keys[key] = struct{}{}
+	index, err := s.marshalIndex()
+	if err != nil {
+		return err
+	}
+	if err := s.client.Batch(ctx, storage.SetOperation(key, data), storage.SetOperation(pendingIndexKey, index)); err != nil {
+		delete(s.keys, key)
+		return err
+	}
+	return nil
+}
+
+// remove deletes the item once it does not need to be replayed anymore
+func (s *pendingStore) remove(ctx context.Context, item workItem) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := pendingItemKey(item)
+	delete(s.keys, key)
+	index, err := s.marshalIndex()
+	if err != nil {
+		return err
+	}
+	return s.client.Batch(ctx, storage.DeleteOperation(key), storage.SetOperation(pendingIndexKey, index))
+}
+
+func (s *pendingStore) marshalIndex() ([]byte, error) {
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return json.Marshal(keys)
+}
+
+// loadCheckpoint returns the time saved under name, or the zero time if there is none
+func (s *pendingStore) loadCheckpoint(ctx context.Context, name string) (time.Time, error) {
+	var checkpoint time.Time
+	if s == nil {
+		return checkpoint, nil
+	}
+	data, err := s.client.Get(ctx, checkpointKey(name))
+	if err != nil || data == nil {
+		return checkpoint, err
+	}
+	if err := checkpoint.UnmarshalText(data); err != nil {
+		return checkpoint, fmt.Errorf("failed to decode checkpoint %q: %w", name, err)
+	}
+	return checkpoint, nil
+}
+
+// saveCheckpoint saves the time reached by a long running task under name
+func (s *pendingStore) saveCheckpoint(ctx context.Context, name string, checkpoint time.Time) error {
+	if s == nil {
+		return nil
+	}
+	data, err := checkpoint.MarshalText()
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, checkpointKey(name), data)
+}
+
+func checkpointKey(name string) string {
+	return fmt.Sprintf("checkpoint_%s", name)
+}
+
+func (s *pendingStore) close(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	return s.client.Close(ctx)
+}
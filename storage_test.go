@@ -0,0 +1,175 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+// memoryStorage is a storage extension keeping the data in memory, shared by all its clients
+type memoryStorage struct {
+	component.StartFunc
+	component.ShutdownFunc
+	mu   sync.Mutex
+	data map[string][]byte
+	// setErr, if set, fails the writes
+	setErr error
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{data: make(map[string][]byte)}
+}
+
+func (m *memoryStorage) GetClient(context.Context, component.Kind, component.ID, string) (storage.Client, error) {
+	return &memoryStorageClient{storage: m}, nil
+}
+
+type memoryStorageClient struct {
+	storage *memoryStorage
+}
+
+func (c *memoryStorageClient) Get(_ context.Context, key string) ([]byte, error) {
+	c.storage.mu.Lock()
+	defer c.storage.mu.Unlock()
+	return c.storage.data[key], nil
+}
+
+func (c *memoryStorageClient) Set(_ context.Context, key string, value []byte) error {
+	c.storage.mu.Lock()
+	defer c.storage.mu.Unlock()
+	if c.storage.setErr != nil {
+		return c.storage.setErr
+	}
+	c.storage.data[key] = value
+	return nil
+}
+
+func (c *memoryStorageClient) Delete(_ context.Context, key string) error {
+	c.storage.mu.Lock()
+	defer c.storage.mu.Unlock()
+	delete(c.storage.data, key)
+	return nil
+}
+
+func (c *memoryStorageClient) Batch(ctx context.Context, ops ...storage.Operation) error {
+	for _, op := range ops {
+		var err error
+		switch op.Type {
+		case storage.Get:
+			op.Value, err = c.Get(ctx, op.Key)
+		case storage.Set:
+			err = c.Set(ctx, op.Key, op.Value)
+		case storage.Delete:
+			err = c.Delete(ctx, op.Key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *memoryStorageClient) Close(context.Context) error {
+	return nil
+}
+
+// storageHost is a host exposing a single storage extension
+type storageHost struct {
+	component.Host
+	extensions map[component.ID]component.Component
+}
+
+func newStorageHost(id component.ID, ext storage.Extension) component.Host {
+	return &storageHost{
+		Host:       componenttest.NewNopHost(),
+		extensions: map[component.ID]component.Component{id: ext},
+	}
+}
+
+func (h *storageHost) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+func TestPendingStoreAddLoadRemove(t *testing.T) {
+	// arrange
+	storageID := component.MustNewID("file_storage")
+	host := newStorageHost(storageID, newMemoryStorage())
+	store, err := newPendingStore(context.Background(), host, &storageID, component.MustNewID("githubactionsannotations"))
+	require.NoError(t, err)
+	first := workItem{event: newWorkflowJobEvent(1), dedupKeys: []string{jobDedupKey(1, 1)}}
+	second := workItem{event: newWorkflowJobEvent(2), dedupKeys: []string{jobDedupKey(2, 1)}}
+	require.NoError(t, store.add(context.Background(), first))
+	require.NoError(t, store.add(context.Background(), second))
+	require.NoError(t, store.remove(context.Background(), first))
+
+	// act
+	reopened, err := newPendingStore(context.Background(), host, &storageID, component.MustNewID("githubactionsannotations"))
+	require.NoError(t, err)
+	items, err := reopened.load(context.Background())
+
+	// assert
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, int64(2), items[0].event.GetWorkflowJob().GetID())
+	assert.Equal(t, []string{jobDedupKey(2, 1)}, items[0].dedupKeys)
+}
+
+func TestPendingStoreMissingExtension(t *testing.T) {
+	storageID := component.MustNewID("file_storage")
+
+	_, err := newPendingStore(context.Background(), componenttest.NewNopHost(), &storageID, component.MustNewID("githubactionsannotations"))
+
+	assert.EqualError(t, err, "storage extension \"file_storage\" not found")
+}
+
+func TestPendingStoreDisabled(t *testing.T) {
+	store, err := newPendingStore(context.Background(), componenttest.NewNopHost(), nil, component.MustNewID("githubactionsannotations"))
+
+	assert.NoError(t, err)
+	assert.Nil(t, store)
+	assert.NoError(t, store.add(context.Background(), workItem{event: newWorkflowJobEvent(1)}))
+}
+
+func TestPendingStoreCheckRunItem(t *testing.T) {
+	// arrange
+	storageID := component.MustNewID("file_storage")
+	host := newStorageHost(storageID, newMemoryStorage())
+	store, err := newPendingStore(context.Background(), host, &storageID, component.MustNewID("githubactionsannotations"))
+	require.NoError(t, err)
+	require.NoError(t, store.add(context.Background(), workItem{checkRunEvent: newCheckRunEvent(1, "codeql"), dedupKeys: []string{checkRunDedupKey(1)}}))
+
+	// act
+	items, err := store.load(context.Background())
+
+	// assert
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Nil(t, items[0].event)
+	assert.Equal(t, int64(1), items[0].checkRunEvent.GetCheckRun().GetID())
+	assert.Equal(t, "codeql", items[0].checkRunEvent.GetCheckRun().GetApp().GetSlug())
+}
+
+func TestPendingStoreWorkflowRunItem(t *testing.T) {
+	// arrange
+	storageID := component.MustNewID("file_storage")
+	host := newStorageHost(storageID, newMemoryStorage())
+	store, err := newPendingStore(context.Background(), host, &storageID, component.MustNewID("githubactionsannotations"))
+	require.NoError(t, err)
+	require.NoError(t, store.add(context.Background(), workItem{workflowRunEvent: newWorkflowRunEvent(1), dedupKeys: []string{workflowRunDedupKey(1, 1)}}))
+
+	// act
+	items, err := store.load(context.Background())
+
+	// assert
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Nil(t, items[0].event)
+	assert.Equal(t, int64(1), items[0].workflowRunEvent.GetWorkflowRun().GetID())
+	assert.Equal(t, []string{workflowRunDedupKey(1, 1)}, items[0].dedupKeys)
+}
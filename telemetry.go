@@ -0,0 +1,84 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/multierr"
+)
+
+const scopeName = "github.com/v1v/opentelemetry-github-actions-annotations-receiver"
+
+// receiverTelemetry holds the instruments the receiver reports through the collector's own telemetry
+type receiverTelemetry struct {
+	queueDropped         metric.Int64Counter
+	duplicatesSuppressed metric.Int64Counter
+	annotationsFiltered  metric.Int64Counter
+	registration         metric.Registration
+}
+
+func newReceiverTelemetry(meterProvider metric.MeterProvider, queue *workQueue, ghClients *githubClients) (*receiverTelemetry, error) {
+	meter := meterProvider.Meter(scopeName)
+	var errs, err error
+	telemetry := &receiverTelemetry{}
+	telemetry.queueDropped, err = meter.Int64Counter(
+		"receiver_githubactionsannotations_queue_dropped",
+		metric.WithDescription("Number of webhooks dropped or rejected because the queue was full"),
+	)
+	errs = multierr.Append(errs, err)
+	telemetry.duplicatesSuppressed, err = meter.Int64Counter(
+		"receiver_githubactionsannotations_duplicates_suppressed",
+		metric.WithDescription("Number of webhooks ignored because they were already accepted"),
+	)
+	errs = multierr.Append(errs, err)
+	telemetry.annotationsFiltered, err = meter.Int64Counter(
+		"receiver_githubactionsannotations_annotations_filtered",
+		metric.WithDescription("Number of annotations dropped by the annotation filters"),
+	)
+	errs = multierr.Append(errs, err)
+	queueSize, err := meter.Int64ObservableGauge(
+		"receiver_githubactionsannotations_queue_size",
+		metric.WithDescription("Current number of webhooks waiting in the queue"),
+	)
+	errs = multierr.Append(errs, err)
+	queueCapacity, err := meter.Int64ObservableGauge(
+		"receiver_githubactionsannotations_queue_capacity",
+		metric.WithDescription("Capacity of the webhook queue"),
+	)
+	errs = multierr.Append(errs, err)
+	rateLimitLimit, err := meter.Int64ObservableGauge(
+		"receiver_githubactionsannotations_github_rate_limit",
+		metric.WithDescription("GitHub API rate limit of the last response, per GitHub App installation"),
+	)
+	errs = multierr.Append(errs, err)
+	rateLimitRemaining, err := meter.Int64ObservableGauge(
+		"receiver_githubactionsannotations_github_rate_limit_remaining",
+		metric.WithDescription("GitHub API requests remaining in the rate limit according to the last response"),
+	)
+	errs = multierr.Append(errs, err)
+	if errs != nil {
+		return nil, errs
+	}
+	telemetry.registration, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(queueSize, int64(queue.size()))
+		o.ObserveInt64(queueCapacity, int64(queue.capacity()))
+		ghClients.rateLimitStates(func(installationID int64, limit int, remaining int) {
+			var opts []metric.ObserveOption
+			if installationID != 0 {
+				opts = append(opts, metric.WithAttributes(attribute.Int64("github.installation.id", installationID)))
+			}
+			o.ObserveInt64(rateLimitLimit, int64(limit), opts...)
+			o.ObserveInt64(rateLimitRemaining, int64(remaining), opts...)
+		})
+		return nil
+	}, queueSize, queueCapacity, rateLimitLimit, rateLimitRemaining)
+	if err != nil {
+		return nil, err
+	}
+	return telemetry, nil
+}
+
+func (t *receiverTelemetry) shutdown() error {
+	return t.registration.Unregister()
+}
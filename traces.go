@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
 // generateTraceID generates a trace ID from the run ID and run attempt
@@ -26,6 +27,33 @@ func generateTraceID(runID int64, runAttempt int) (pcommon.TraceID, error) {
 	return traceID, nil
 }
 
+// generateParentSpanID generates the span ID of the workflow run span from the run ID and run attempt
+// Follows https://github.com/open-telemetry/opentelemetry-collector-contrib/blob/b45f1739c29039a0314b9c97c3ccc578562df36f/receiver/githubactionsreceiver/trace_event_handling.go
+// to be able to correlate spans with traces from the githubactionsreceiver
+func generateParentSpanID(runID int64, runAttempt int) (pcommon.SpanID, error) {
+	return generateSpanID(fmt.Sprintf("%d%ds", runID, runAttempt))
+}
+
+// generateJobSpanID generates the span ID of a job span from the run ID, run attempt and job name
+// Follows https://github.com/open-telemetry/opentelemetry-collector-contrib/blob/b45f1739c29039a0314b9c97c3ccc578562df36f/receiver/githubactionsreceiver/trace_event_handling.go
+// to be able to correlate spans with traces from the githubactionsreceiver
+func generateJobSpanID(runID int64, runAttempt int, jobName string) (pcommon.SpanID, error) {
+	return generateSpanID(fmt.Sprintf("%d%d%s", runID, runAttempt, jobName))
+}
+
+func generateSpanID(input string) (pcommon.SpanID, error) {
+	hash := sha256.Sum256([]byte(input))
+	spanIDHex := hex.EncodeToString(hash[:])
+
+	var spanID pcommon.SpanID
+	_, err := hex.Decode(spanID[:], []byte(spanIDHex[16:32]))
+	if err != nil {
+		return pcommon.SpanID{}, err
+	}
+
+	return spanID, nil
+}
+
 // generateServiceName generates a service name from the full name of the repository
 // Copied from https://github.com/open-telemetry/opentelemetry-collector-contrib/blob/b45f1739c29039a0314b9c97c3ccc578562df36f/receiver/githubactionsreceiver/trace_event_handling.go#L254
 // to be able to correlate logs with traces from the githubactionsreceiver
@@ -36,3 +64,44 @@ func generateServiceName(config *Config, fullName string) string {
 	formattedName := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(fullName, "/", "-"), "_", "-"))
 	return fmt.Sprintf("%s%s%s", config.ServiceNamePrefix, formattedName, config.ServiceNameSuffix)
 }
+
+// attachJobSpanData turns the span into the job span, child of the workflow run span
+func attachJobSpanData(span *ptrace.Span, repository Repository, run Run, job Job) error {
+	traceID, err := generateTraceID(run.ID, int(run.RunAttempt))
+	if err != nil {
+		return err
+	}
+	parentSpanID, err := generateParentSpanID(run.ID, int(run.RunAttempt))
+	if err != nil {
+		return err
+	}
+	spanID, err := generateJobSpanID(run.ID, int(run.RunAttempt), job.Name)
+	if err != nil {
+		return err
+	}
+	span.SetTraceID(traceID)
+	span.SetParentSpanID(parentSpanID)
+	span.SetSpanID(spanID)
+	span.SetName(job.Name)
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(run.RunStartedAt))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(run.CompletedAt))
+	switch run.Conclusion {
+	case "success":
+		span.Status().SetCode(ptrace.StatusCodeOk)
+	case "failure":
+		span.Status().SetCode(ptrace.StatusCodeError)
+	}
+	attachRepositoryAttributes(span.Attributes(), repository)
+	attachRunAttributes(span.Attributes(), run)
+	attachJobAttributes(span.Attributes(), job)
+	return nil
+}
+
+// attachSpanEventData turns the span event into an annotation
+func attachSpanEventData(spanEvent *ptrace.SpanEvent, annotation Annotation, logLine LogLine) {
+	spanEvent.SetName("github.annotation")
+	spanEvent.SetTimestamp(pcommon.NewTimestampFromTime(logLine.Timestamp))
+	spanEvent.Attributes().PutStr("github.annotation.level", logLine.SeverityText)
+	spanEvent.Attributes().PutStr("github.annotation.message", logLine.Body)
+	attachAnnotationAttributes(spanEvent.Attributes(), annotation)
+}
@@ -0,0 +1,79 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestGenerateJobSpanIDIsDeterministic(t *testing.T) {
+	first, err := generateJobSpanID(1, 1, "build")
+	require.NoError(t, err)
+	second, err := generateJobSpanID(1, 1, "build")
+	require.NoError(t, err)
+	otherJob, err := generateJobSpanID(1, 1, "test")
+	require.NoError(t, err)
+	otherAttempt, err := generateJobSpanID(1, 2, "build")
+	require.NoError(t, err)
+
+	// computed with the githubactionsreceiver trace_event_handling.go, a different ID breaks the correlation
+	assert.Equal(t, "104679d964824827", first.String())
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, otherJob)
+	assert.NotEqual(t, first, otherAttempt)
+}
+
+func TestAttachJobSpanData(t *testing.T) {
+	// arrange
+	span := ptrace.NewSpan()
+	startedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	completedAt := startedAt.Add(time.Minute)
+	run := Run{ID: 1, RunAttempt: 2, Conclusion: "failure", RunStartedAt: startedAt, CompletedAt: completedAt}
+	job := Job{ID: 3, Name: "build"}
+
+	// act
+	err := attachJobSpanData(&span, Repository{FullName: "owner/repo"}, run, job)
+
+	// assert
+	require.NoError(t, err)
+	// computed with the githubactionsreceiver trace_event_handling.go for the run 1, attempt 2 and job build
+	assert.Equal(t, "71059479ce9b411f4a37ecdb6bcaaf7b", span.TraceID().String())
+	assert.Equal(t, "acc8d7d012ae4a68", span.ParentSpanID().String())
+	assert.Equal(t, "dfa5d606fd513bb9", span.SpanID().String())
+	assert.Equal(t, "build", span.Name())
+	assert.Equal(t, startedAt, span.StartTimestamp().AsTime())
+	assert.Equal(t, completedAt, span.EndTimestamp().AsTime())
+	assert.Equal(t, ptrace.StatusCodeError, span.Status().Code())
+	assert.Equal(t, "owner/repo", span.Attributes().AsRaw()["github.repository"])
+	assert.Equal(t, int64(3), span.Attributes().AsRaw()["github.workflow_job.id"])
+}
+
+func TestAttachSpanEventData(t *testing.T) {
+	// arrange
+	spanEvent := ptrace.NewSpanEvent()
+	annotation := &checkRunAnnotation{
+		CheckRunAnnotation: github.CheckRunAnnotation{
+			Path:            github.String("main.go"),
+			StartLine:       github.Int(10),
+			AnnotationLevel: github.String("warning"),
+			Message:         github.String("unused variable"),
+		},
+	}
+	completedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// act
+	attachSpanEventData(&spanEvent, mapAnnotation(annotation), parseAnnotationToLogLine(completedAt, &annotation.CheckRunAnnotation))
+
+	// assert
+	assert.Equal(t, "github.annotation", spanEvent.Name())
+	assert.Equal(t, completedAt, spanEvent.Timestamp().AsTime())
+	attrs := spanEvent.Attributes().AsRaw()
+	assert.Equal(t, "warning", attrs["github.annotation.level"])
+	assert.Equal(t, "unused variable", attrs["github.annotation.message"])
+	assert.Equal(t, "main.go", attrs["code.filepath"])
+	assert.Equal(t, int64(10), attrs["code.lineno"])
+}
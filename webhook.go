@@ -0,0 +1,110 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/multierr"
+)
+
+// webhookSecret is a secret accepted for the webhook signatures, either configured
+// or read from a file that is read again when it changes
+type webhookSecret struct {
+	// name identifies the secret in the logs without revealing it
+	name  string
+	value []byte
+	path  string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+}
+
+// webhookSecrets are the secrets accepted for the webhook signatures. Accepting several
+// secrets allows rotating them without rejecting the webhooks signed with the previous one.
+type webhookSecrets []*webhookSecret
+
+func newWebhookSecrets(cfg *Config) webhookSecrets {
+	var secrets webhookSecrets
+	if cfg.WebhookSecret != "" {
+		secrets = append(secrets, &webhookSecret{name: "webhook_secret", value: []byte(cfg.WebhookSecret)})
+	}
+	for i, secret := range cfg.WebhookSecrets {
+		secrets = append(secrets, &webhookSecret{name: fmt.Sprintf("webhook_secrets[%d]", i), value: []byte(secret)})
+	}
+	for i, path := range cfg.WebhookSecretFiles {
+		secrets = append(secrets, &webhookSecret{name: fmt.Sprintf("webhook_secret_files[%d]", i), path: path})
+	}
+	return secrets
+}
+
+// get returns the secret, reading its file again if it changed since the last read
+func (s *webhookSecret) get() ([]byte, error) {
+	if s.path == "" {
+		return s.value, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.name, err)
+	}
+	if s.value != nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.value, nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.name, err)
+	}
+	s.value = []byte(strings.TrimSpace(string(data)))
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	return s.value, nil
+}
+
+// validate reads the payload of the webhook and checks its signature against each secret.
+// It returns the payload and the name of the secret that matched. Without any secret,
+// the signature is not checked.
+func (secrets webhookSecrets) validate(r *http.Request) ([]byte, string, error) {
+	signature := r.Header.Get(github.SHA256SignatureHeader)
+	if signature == "" {
+		signature = r.Header.Get(github.SHA1SignatureHeader)
+	}
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, "", err
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(secrets) == 0 {
+		payload, err := github.ValidatePayloadFromBody(contentType, bytes.NewReader(body), signature, nil)
+		return payload, "", err
+	}
+	var errs error
+	for _, secret := range secrets {
+		value, err := secret.get()
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		if len(value) == 0 {
+			continue
+		}
+		payload, err := github.ValidatePayloadFromBody(contentType, bytes.NewReader(body), signature, value)
+		if err == nil {
+			return payload, secret.name, nil
+		}
+	}
+	return nil, "", multierr.Append(errors.New("payload signature check failed for all the webhook secrets"), errs)
+}
@@ -0,0 +1,83 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/config/configopaque"
+)
+
+func newSignedRequest(t *testing.T, secret string, payload []byte) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	req, err := http.NewRequest(http.MethodPost, "/githubactionsannotations", bytes.NewReader(payload))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(github.SHA256SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestWebhookSecretsValidateTriesEachSecret(t *testing.T) {
+	// arrange
+	secrets := newWebhookSecrets(&Config{
+		WebhookSecret:  "old",
+		WebhookSecrets: []configopaque.String{"new"},
+	})
+	payload := []byte(`{"action":"completed"}`)
+
+	// act
+	validated, secretName, err := secrets.validate(newSignedRequest(t, "new", payload))
+
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, payload, validated)
+	assert.Equal(t, "webhook_secrets[0]", secretName)
+}
+
+func TestWebhookSecretsValidateRejectsUnknownSecret(t *testing.T) {
+	secrets := newWebhookSecrets(&Config{WebhookSecrets: []configopaque.String{"old", "new"}})
+
+	_, _, err := secrets.validate(newSignedRequest(t, "other", []byte(`{}`)))
+
+	assert.EqualError(t, err, "payload signature check failed for all the webhook secrets")
+}
+
+func TestWebhookSecretsValidateRereadsChangedSecretFile(t *testing.T) {
+	// arrange
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o600))
+	secrets := newWebhookSecrets(&Config{WebhookSecretFiles: []string{path}})
+	_, _, err := secrets.validate(newSignedRequest(t, "old", []byte(`{}`)))
+	require.NoError(t, err)
+
+	// act
+	require.NoError(t, os.WriteFile(path, []byte("rotated\n"), 0o600))
+	_, secretName, err := secrets.validate(newSignedRequest(t, "rotated", []byte(`{}`)))
+
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, "webhook_secret_files[0]", secretName)
+	_, _, err = secrets.validate(newSignedRequest(t, "old", []byte(`{}`)))
+	assert.Error(t, err)
+}
+
+func TestWebhookSecretsValidateWithoutSecret(t *testing.T) {
+	var secrets webhookSecrets
+	req := newSignedRequest(t, "any", []byte(`{}`))
+	req.Header.Del(github.SHA256SignatureHeader)
+
+	payload, secretName, err := secrets.validate(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`{}`), payload)
+	assert.Empty(t, secretName)
+}
@@ -0,0 +1,209 @@
+package opentelemetrygithubactionsannotationsreceiver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// handleWorkflowRunEvent queues completed workflow runs, whose jobs are listed
+// and processed one by one by the workers
+func (rec *githubactionsannotationsreceiver) handleWorkflowRunEvent(ctx context.Context, event *github.WorkflowRunEvent, w http.ResponseWriter, r *http.Request) {
+	rec.logger.Debug("Handling workflow run event", zap.Int64("workflow_run.id", event.GetWorkflowRun().GetID()))
+	if event.GetAction() != "completed" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if !rec.filter.allowsRun(event.GetRepo(), event.GetWorkflowRun()) {
+		rec.logger.Debug("Skipping workflow run excluded by the filters", newWorkflowRunInfoFields(event)()...)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	dedupKeys := []string{workflowRunDedupKey(event.GetWorkflowRun().GetID(), int64(event.GetWorkflowRun().GetRunAttempt()))}
+	rec.enqueue(ctx, workItem{workflowRunEvent: event, dedupKeys: dedupKeys}, w, r)
+}
+
+// newWorkflowRunInfoFields returns a function prepending the workflow run identity to log fields
+func newWorkflowRunInfoFields(event *github.WorkflowRunEvent) func(fields ...zap.Field) []zap.Field {
+	return func(fields ...zap.Field) []zap.Field {
+		workflowRunInfoFields := []zap.Field{
+			zap.String("github.repository", event.GetRepo().GetFullName()),
+			zap.String("github.workflow_run.name", event.GetWorkflowRun().GetName()),
+			zap.Int64("github.workflow_run.id", event.GetWorkflowRun().GetID()),
+			zap.Int("github.workflow_run.run_attempt", event.GetWorkflowRun().GetRunAttempt()),
+		}
+		return append(workflowRunInfoFields, fields...)
+	}
+}
+
+// processWorkflowRunEvent lists the jobs of the run attempt and processes each of them as
+// a completed workflow_job event. Jobs already accepted from a workflow_job webhook are
+// skipped, and the workflow_job webhooks of the jobs processed here are suppressed.
+func (rec *githubactionsannotationsreceiver) processWorkflowRunEvent(
+	ctx context.Context,
+	ghClient *github.Client,
+	withWorkflowRunInfoFields func(fields ...zap.Field) []zap.Field,
+	event *github.WorkflowRunEvent,
+) error {
+	jobs, err := rec.listWorkflowRunJobs(ctx, ghClient, withWorkflowRunInfoFields, event.GetRepo(), event.GetWorkflowRun().GetID(), int64(event.GetWorkflowRun().GetRunAttempt()))
+	if err != nil {
+		return err
+	}
+	rec.logger.Debug("Listed workflow run jobs", withWorkflowRunInfoFields(zap.Int("job_count", len(jobs)))...)
+
+	var errs error
+	for _, job := range jobs {
+		if job.GetStatus() != "completed" {
+			continue
+		}
+		jobEvent := newWorkflowJobEventFromRun(event, job)
+		withWorkflowInfoFields := newWorkflowInfoFields(jobEvent)
+		if !rec.filter.allowsJob(jobEvent.GetRepo(), job) {
+			rec.logger.Debug("Skipping workflow run job excluded by the filters", withWorkflowInfoFields()...)
+			continue
+		}
+		key := jobDedupKey(job.GetID(), job.GetRunAttempt())
+		if !rec.dedup.addIfAbsent(key) {
+			rec.logger.Debug("Skipping workflow run job already processed", withWorkflowInfoFields()...)
+			rec.telemetry.duplicatesSuppressed.Add(ctx, 1)
+			continue
+		}
+		if err := rec.processWorkflowJobEvent(ctx, ghClient, withWorkflowInfoFields, jobEvent); err != nil {
+			rec.dedup.remove(key)
+			errs = multierr.Append(errs, err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return errs
+}
+
+// newWorkflowJobEventFromRun wraps a job listed from the Actions API into the workflow_job event
+// GitHub would have sent for it. The run fields missing from the job are taken from the run.
+func newWorkflowJobEventFromRun(event *github.WorkflowRunEvent, job *github.WorkflowJob) *github.WorkflowJobEvent {
+	if job.WorkflowName == nil {
+		job.WorkflowName = event.GetWorkflowRun().Name
+	}
+	if job.HeadBranch == nil {
+		job.HeadBranch = event.GetWorkflowRun().HeadBranch
+	}
+	if job.RunAttempt == nil {
+		job.RunAttempt = github.Int64(int64(event.GetWorkflowRun().GetRunAttempt()))
+	}
+	if job.RunURL == nil {
+		job.RunURL = event.GetWorkflowRun().URL
+	}
+	return &github.WorkflowJobEvent{
+		Action:       github.String("completed"),
+		WorkflowJob:  job,
+		Repo:         event.GetRepo(),
+		Org:          event.GetOrg(),
+		Sender:       event.GetSender(),
+		Installation: event.GetInstallation(),
+	}
+}
+
+// listWorkflowRunJobs lists all the jobs of a workflow run attempt
+func (rec *githubactionsannotationsreceiver) listWorkflowRunJobs(ctx context.Context, ghClient *github.Client, withInfoFields func(fields ...zap.Field) []zap.Field, repo *github.Repository, runID int64, runAttempt int64) ([]*github.WorkflowJob, error) {
+	listOpts := &github.ListOptions{
+		PerPage: 100,
+	}
+	var allJobs []*github.WorkflowJob
+	for {
+		var jobs *github.Jobs
+		var response *github.Response
+		err := rec.retryGitHubCall(ctx, withInfoFields, func(ctx context.Context) error {
+			var err error
+			jobs, response, err = ghClient.Actions.ListWorkflowJobsAttempt(ctx, repo.GetOwner().GetLogin(), repo.GetName(), runID, runAttempt, listOpts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list workflow run jobs: %w", err)
+		}
+		allJobs = append(allJobs, jobs.Jobs...)
+		if response.NextPage == 0 {
+			break
+		}
+		listOpts.Page = response.NextPage
+	}
+	return allJobs, nil
+}
+
+// collectWorkflowRun processes a listed run as a completed workflow_run event, unless
+// it was already received as a webhook or is excluded by the filters
+func (rec *githubactionsannotationsreceiver) collectWorkflowRun(ctx context.Context, ghClient *github.Client, repo *github.Repository, run *github.WorkflowRun) error {
+	event := &github.WorkflowRunEvent{
+		Action:      github.String("completed"),
+		WorkflowRun: run,
+		Repo:        repo,
+	}
+	withInfoFields := newWorkflowRunInfoFields(event)
+	if !rec.filter.allowsRun(repo, run) {
+		rec.logger.Debug("Skipping workflow run excluded by the filters", withInfoFields()...)
+		return nil
+	}
+	key := workflowRunDedupKey(run.GetID(), int64(run.GetRunAttempt()))
+	if !rec.dedup.addIfAbsent(key) {
+		rec.logger.Debug("Skipping workflow run already processed", withInfoFields()...)
+		return nil
+	}
+	if err := rec.processWorkflowRunEvent(ctx, ghClient, withInfoFields, event); err != nil {
+		rec.dedup.remove(key)
+		return fmt.Errorf("failed to collect workflow run %d: %w", run.GetID(), err)
+	}
+	return nil
+}
+
+// listCompletedWorkflowRuns lists the completed workflow runs of the repository created between from and to
+func (rec *githubactionsannotationsreceiver) listCompletedWorkflowRuns(ctx context.Context, ghClient *github.Client, repo *github.Repository, from time.Time, to time.Time) ([]*github.WorkflowRun, error) {
+	listOpts := &github.ListWorkflowRunsOptions{
+		Status:  "completed",
+		Created: fmt.Sprintf("%s..%s", from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339)),
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	var allRuns []*github.WorkflowRun
+	for {
+		var runs *github.WorkflowRuns
+		var response *github.Response
+		err := rec.retryGitHubCall(ctx, newRepositoryInfoFields(repo.GetFullName()), func(ctx context.Context) error {
+			var err error
+			runs, response, err = ghClient.Actions.ListRepositoryWorkflowRuns(ctx, repo.GetOwner().GetLogin(), repo.GetName(), listOpts)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list workflow runs: %w", err)
+		}
+		allRuns = append(allRuns, runs.WorkflowRuns...)
+		if response.NextPage == 0 {
+			break
+		}
+		listOpts.Page = response.NextPage
+	}
+	return allRuns, nil
+}
+
+// newRepositoryInfoFields returns a function prepending the repository to log fields
+func newRepositoryInfoFields(fullName string) func(fields ...zap.Field) []zap.Field {
+	return func(fields ...zap.Field) []zap.Field {
+		return append([]zap.Field{zap.String("github.repository", fullName)}, fields...)
+	}
+}
+
+// newRepository returns the repository identified by its "owner/name" full name
+func newRepository(fullName string) *github.Repository {
+	owner, name, _ := strings.Cut(fullName, "/")
+	return &github.Repository{
+		FullName: github.String(fullName),
+		Name:     github.String(name),
+		Owner:    &github.User{Login: github.String(owner)},
+	}
+}